@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+)
+
+func TestParseWIT(t *testing.T) {
+	c := is.NewRelaxed(t)
+	src, err := os.ReadFile("testdata/demo.wit")
+	is.Err(is.Not(c), err)
+	file, err := parseWIT(string(src))
+	is.Err(is.Not(c), err)
+	is.Equal(c, file.pkg, "example:demo@0.1.0")
+	is.Equal(c, len(file.interfaces), 1)
+
+	iface := file.interfaces[0]
+	is.Equal(c, iface.name, "greeter")
+	is.SliceEqual(c, iface.docs, []string{"Greeting things."})
+	is.Equal(c, len(iface.types), 6)
+	is.Equal(c, len(iface.funcs), 6)
+
+	point := iface.types[0]
+	is.Equal(c, point.kind, "record")
+	is.Equal(c, len(point.fields), 2)
+	is.Equal(c, point.fields[1].typ.name, "f32")
+
+	color := iface.types[1]
+	is.SliceEqual(c, color.names, []string{"red", "green", "blue"})
+
+	shape := iface.types[3]
+	is.Equal(c, len(shape.fields), 3)
+	is.Equal(c, shape.fields[1].typ.name, "tuple")
+	is.True(c, shape.fields[2].typ == nil)
+
+	file2 := iface.types[5]
+	is.Equal(c, file2.kind, "resource")
+	is.Equal(c, len(file2.funcs), 3)
+	is.Equal(c, file2.funcs[0].kind, "constructor")
+	is.Equal(c, file2.funcs[1].kind, "method")
+	is.Equal(c, file2.funcs[2].kind, "static")
+
+	paint := iface.funcs[1]
+	is.Equal(c, paint.name, "paint")
+	is.Equal(c, paint.params[2].name, "type")
+	is.Equal(c, paint.result.name, "result")
+	is.True(c, paint.result.args[0] == nil)
+	is.Equal(c, paint.result.args[1].name, "string")
+
+	noop := iface.funcs[4]
+	is.True(c, noop.result == nil)
+}
+
+func TestParseWIT_Errors(t *testing.T) {
+	c := is.NewRelaxed(t)
+	cases := []string{
+		"interface a { f: func(; }",
+		"interface a { f: func() -> (a: u32); }",
+		"interface a { record r { x: u32 }",
+		"interface a { f: func() -> list<u32; }",
+		"interface a { f: func() # }",
+		"interface a { type t = t; }",
+		"interface a { type t = list<u>; type u = option<t>; }",
+		"interface a { record r { x: list<r> } }",
+	}
+	for _, src := range cases {
+		_, err := parseWIT(src)
+		is.Err(c, err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c := is.NewRelaxed(t)
+	src, err := os.ReadFile("testdata/demo.wit")
+	is.Err(is.Not(c), err)
+	file, err := parseWIT(string(src))
+	is.Err(is.Not(c), err)
+	code, err := generate(file, "bindings")
+	is.Err(is.Not(c), err)
+
+	expected := []string{
+		"package bindings",
+		"type Point = wypes.Record[PointFields, *PointFields]",
+		"type Color = wypes.Enum[ColorCases]",
+		"type Perms = wypes.Flags[PermsFlags]",
+		"type Shape = wypes.Variant3[wypes.Float32, wypes.Tuple2[wypes.Float32, wypes.Float32], wypes.Void]",
+		"type Points = wypes.List[Point]",
+		"type File any",
+		`const GreeterModuleName = "example:demo/greeter@0.1.0"`,
+		"Hello(name wypes.String) wypes.String",
+		"Paint(p Point, c Color, type_ Perms) wypes.Variant2[wypes.Void, wypes.String]",
+		"FileRead(self wypes.Borrow[File], n wypes.UInt32) wypes.Variant2[wypes.Bytes, wypes.String]",
+		`"many":  wypes.H2(impl.Many),`,
+		`"read": wypes.H2(impl.FileRead),`,
+		"Destructor: impl.DropFile,",
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(code), line) {
+			t.Errorf("generated code doesn't contain %q", line)
+		}
+	}
+}
+
+func TestGenerate_Compiles(t *testing.T) {
+	c := is.NewRelaxed(t)
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	src, err := os.ReadFile("testdata/demo.wit")
+	is.Err(is.Not(c), err)
+	file, err := parseWIT(string(src))
+	is.Err(is.Not(c), err)
+	code, err := generate(file, "bindings")
+	is.Err(is.Not(c), err)
+
+	// the generated code is built as a module depending on the wypes source tree
+	root, err := filepath.Abs("../..")
+	is.Err(is.Not(c), err)
+	dir := t.TempDir()
+	goMod := fmt.Sprintf(
+		"module example.com/bindings\n\ngo 1.21\n\nrequire github.com/orsinium-labs/wypes v0.0.0\n\nreplace github.com/orsinium-labs/wypes => %s\n",
+		root,
+	)
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	is.Err(is.Not(c), err)
+	is.Err(is.Not(c), os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644))
+	is.Err(is.Not(c), os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+	is.Err(is.Not(c), os.WriteFile(filepath.Join(dir, "bindings.go"), code, 0o644))
+	for _, sub := range []string{"build", "vet"} {
+		cmd := exec.Command(goBin, sub, "./...")
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Errorf("go %s: %v\n%s", sub, err, out)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	c := is.NewRelaxed(t)
+	cases := []string{
+		"interface a { f: func(x: unknown); }",
+		"interface a { variant v { x } }",
+		"interface a { f: func(x: tuple<u32>); }",
+		"interface a { f: func(x: borrow<u32>); }",
+	}
+	for _, src := range cases {
+		file, err := parseWIT(src)
+		is.Err(is.Not(c), err)
+		_, err = generate(file, "bindings")
+		is.Err(c, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out bytes.Buffer
+	err := run([]string{"-pkg", "demo", "testdata/demo.wit"}, &out)
+	is.Err(is.Not(c), err)
+	is.True(c, strings.HasPrefix(out.String(), "// Code generated by wypes-bindgen. DO NOT EDIT."))
+
+	err = run([]string{}, &out)
+	is.Err(c, err)
+}
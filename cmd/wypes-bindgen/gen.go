@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+)
+
+// maxParams is the number of params supported by the biggest [wypes.H18].
+const maxParams = 18
+
+// primitives maps WIT primitive types to wypes types.
+var primitives = map[string]string{
+	"bool":    "wypes.Bool",
+	"s8":      "wypes.Int8",
+	"s16":     "wypes.Int16",
+	"s32":     "wypes.Int32",
+	"s64":     "wypes.Int64",
+	"u8":      "wypes.UInt8",
+	"u16":     "wypes.UInt16",
+	"u32":     "wypes.UInt32",
+	"u64":     "wypes.UInt64",
+	"f32":     "wypes.Float32",
+	"f64":     "wypes.Float64",
+	"float32": "wypes.Float32",
+	"float64": "wypes.Float64",
+	"char":    "wypes.Rune",
+	"string":  "wypes.String",
+}
+
+// generator generates Go host bindings for a WIT file.
+type generator struct {
+	file *witFile
+	pkg  string
+	buf  bytes.Buffer
+
+	// kinds maps names of all the types defined in the file to their kind.
+	kinds map[string]string
+}
+
+// generate returns the formatted Go code of the host bindings for the WIT file.
+func generate(file *witFile, pkg string) ([]byte, error) {
+	g := &generator{file: file, pkg: pkg, kinds: map[string]string{}}
+	for _, iface := range file.interfaces {
+		for _, def := range iface.types {
+			g.kinds[def.name] = def.kind
+		}
+	}
+	err := g.genFile()
+	if err != nil {
+		return nil, err
+	}
+	code, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v", err)
+	}
+	return code, nil
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) genFile() error {
+	g.printf("// Code generated by wypes-bindgen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.pkg)
+	g.printf("import \"github.com/orsinium-labs/wypes\"\n")
+	for _, iface := range g.file.interfaces {
+		for _, def := range iface.types {
+			err := g.genTypeDef(def)
+			if err != nil {
+				return fmt.Errorf("type %s: %v", def.name, err)
+			}
+		}
+		err := g.genInterface(iface)
+		if err != nil {
+			return fmt.Errorf("interface %s: %v", iface.name, err)
+		}
+	}
+	return nil
+}
+
+func (g *generator) genTypeDef(def *witTypeDef) error {
+	name := goName(def.name)
+	g.printf("\n")
+	g.genDocs(def.docs)
+	switch def.kind {
+	case "type":
+		typ, err := g.goType(def.alias)
+		if err != nil {
+			return err
+		}
+		g.printf("type %s = %s\n", name, typ)
+	case "record":
+		g.printf("type %s = wypes.Record[%sFields, *%sFields]\n\n", name, name, name)
+		g.printf("// %sFields are the fields of [%s].\n", name, name)
+		g.printf("type %sFields struct {\n", name)
+		for _, f := range def.fields {
+			typ, err := g.goType(f.typ)
+			if err != nil {
+				return err
+			}
+			g.printf("\t%s %s\n", goName(f.name), typ)
+		}
+		g.printf("}\n\n")
+		g.printf("// Fields implements [wypes.RecordFields] interface.\n")
+		g.printf("func (r *%sFields) Fields() []wypes.Field {\n", name)
+		g.printf("\treturn []wypes.Field{\n")
+		for _, f := range def.fields {
+			g.printf("\t\twypes.FieldOf(&r.%s),\n", goName(f.name))
+		}
+		g.printf("\t}\n}\n")
+	case "variant":
+		if len(def.fields) < 2 || len(def.fields) > 8 {
+			return fmt.Errorf("variants must have from 2 to 8 cases, got %d", len(def.fields))
+		}
+		types := make([]string, len(def.fields))
+		for i, f := range def.fields {
+			var err error
+			types[i], err = g.goTypeOrVoid(f.typ)
+			if err != nil {
+				return err
+			}
+		}
+		g.printf("type %s = wypes.Variant%d[%s]\n", name, len(types), strings.Join(types, ", "))
+		g.genConsts(name, def.fields)
+	case "enum":
+		g.printf("type %s = wypes.Enum[%sCases]\n\n", name, name)
+		g.printf("// %sCases are the cases of [%s].\n", name, name)
+		g.printf("type %sCases struct{}\n\n", name)
+		g.printf("// Cases implements [wypes.EnumCases] interface.\n")
+		g.printf("func (%sCases) Cases() []string {\n", name)
+		g.printf("\treturn []string{%s}\n}\n", quoteAll(def.names))
+		g.genConsts(name, namesToFields(def.names))
+	case "flags":
+		g.printf("type %s = wypes.Flags[%sFlags]\n\n", name, name)
+		g.printf("// %sFlags are the flags of [%s].\n", name, name)
+		g.printf("type %sFlags struct{}\n\n", name)
+		g.printf("// Flags implements [wypes.FlagSet] interface.\n")
+		g.printf("func (%sFlags) Flags() []string {\n", name)
+		g.printf("\treturn []string{%s}\n}\n", quoteAll(def.names))
+		g.genConsts(name, namesToFields(def.names))
+	case "resource":
+		if len(def.docs) == 0 {
+			g.printf("// %s is the %q resource.\n", name, def.name)
+		}
+		g.printf("//\n// The host can store any value as the resource.\n")
+		g.printf("type %s any\n", name)
+	}
+	return nil
+}
+
+// genConsts generates constants with the indices of the variant cases, enum cases, or flags.
+func (g *generator) genConsts(name string, fields []witField) {
+	g.printf("\nconst (\n")
+	for i, f := range fields {
+		g.printf("\t%s%s = %d\n", name, goName(f.name), i)
+	}
+	g.printf(")\n")
+}
+
+func (g *generator) genInterface(iface *witInterface) error {
+	name := goName(iface.name)
+	funcs := g.interfaceFuncs(iface)
+
+	g.printf("\n// %sModuleName is the name of the module importing the %q interface.\n", name, iface.name)
+	g.printf("const %sModuleName = %q\n", name, moduleName(g.file.pkg, iface.name))
+
+	g.printf("\n// %s is the host implementation of the %q interface.\n", name, iface.name)
+	if len(iface.docs) > 0 {
+		g.printf("//\n")
+		g.genDocs(iface.docs)
+	}
+	g.printf("type %s interface {\n", name)
+	for i, fn := range funcs {
+		if i > 0 {
+			g.printf("\n")
+		}
+		g.genDocs(fn.docs)
+		sig, err := g.goSignature(fn)
+		if err != nil {
+			return fmt.Errorf("function %s: %v", fn.name, err)
+		}
+		g.printf("\t%s%s\n", fn.goName, sig)
+	}
+	for _, def := range iface.types {
+		if def.kind == "resource" {
+			g.printf("\n\t// %s is called when the guest drops the [%s] resource.\n", dropName(def.name), goName(def.name))
+			g.printf("\t%s(%s)\n", dropName(def.name), goName(def.name))
+		}
+	}
+	g.printf("}\n")
+
+	g.printf("\n// %sModule defines the functions of the %q interface implemented by impl.\n", name, iface.name)
+	g.printf("func %sModule(impl %s) wypes.Module {\n", name, name)
+	g.printf("\tm := wypes.Module{\n")
+	for _, fn := range iface.funcs {
+		g.printf("\t\t%q: %s,\n", fn.name, hostFunc(len(fn.params), goName(fn.name)))
+	}
+	g.printf("\t}\n")
+	for _, def := range iface.types {
+		if def.kind != "resource" {
+			continue
+		}
+		g.genResource(def)
+	}
+	g.printf("\treturn m\n}\n")
+	return nil
+}
+
+// hostFunc returns the definition of the host function calling the given method of impl.
+//
+// Same as the canonical ABI, the host function returns
+// the results that don't fit into 1 value through memory.
+func hostFunc(numParams int, method string) string {
+	return fmt.Sprintf("wypes.H%d(impl.%s)", numParams, method)
+}
+
+func (g *generator) genResource(def *witTypeDef) {
+	name := goName(def.name)
+	g.printf("\twypes.Resource[%s]{\n", name)
+	g.printf("\t\tName: %q,\n", def.name)
+	var methods, statics []*witFunc
+	for _, fn := range def.funcs {
+		switch fn.kind {
+		case "constructor":
+			g.printf("\t\tConstructor: %s,\n", hostFunc(len(fn.params), "New"+name))
+		case "method":
+			methods = append(methods, fn)
+		case "static":
+			statics = append(statics, fn)
+		}
+	}
+	if len(methods) > 0 {
+		g.printf("\t\tMethods: map[string]wypes.HostFunc{\n")
+		for _, fn := range methods {
+			g.printf("\t\t\t%q: %s,\n", fn.name, hostFunc(len(fn.params)+1, name+goName(fn.name)))
+		}
+		g.printf("\t\t},\n")
+	}
+	if len(statics) > 0 {
+		g.printf("\t\tStatics: map[string]wypes.HostFunc{\n")
+		for _, fn := range statics {
+			g.printf("\t\t\t%q: %s,\n", fn.name, hostFunc(len(fn.params), name+goName(fn.name)))
+		}
+		g.printf("\t\t},\n")
+	}
+	g.printf("\t\tDestructor: impl.%s,\n", dropName(def.name))
+	g.printf("\t}.Define(m)\n")
+}
+
+// ifaceFunc is a function of the generated Go interface.
+type ifaceFunc struct {
+	*witFunc
+	goName string
+	// self is the resource type for methods.
+	self string
+}
+
+// interfaceFuncs returns all the functions of the interface,
+// including the functions of the resources defined in it.
+func (g *generator) interfaceFuncs(iface *witInterface) []ifaceFunc {
+	var funcs []ifaceFunc
+	for _, fn := range iface.funcs {
+		funcs = append(funcs, ifaceFunc{witFunc: fn, goName: goName(fn.name)})
+	}
+	for _, def := range iface.types {
+		name := goName(def.name)
+		for _, fn := range def.funcs {
+			switch fn.kind {
+			case "constructor":
+				funcs = append(funcs, ifaceFunc{witFunc: fn, goName: "New" + name})
+			case "method":
+				funcs = append(funcs, ifaceFunc{witFunc: fn, goName: name + goName(fn.name), self: name})
+			case "static":
+				funcs = append(funcs, ifaceFunc{witFunc: fn, goName: name + goName(fn.name)})
+			}
+		}
+	}
+	return funcs
+}
+
+// goSignature returns the Go signature of the function, like "(a wypes.UInt32) wypes.Void".
+func (g *generator) goSignature(fn ifaceFunc) (string, error) {
+	params := []string{}
+	if fn.self != "" {
+		params = append(params, fmt.Sprintf("self wypes.Borrow[%s]", fn.self))
+	}
+	for _, p := range fn.params {
+		typ, err := g.goType(p.typ)
+		if err != nil {
+			return "", err
+		}
+		params = append(params, goParamName(p.name)+" "+typ)
+	}
+	if len(params) > maxParams {
+		return "", fmt.Errorf("too many params: %d", len(params))
+	}
+	var result string
+	var err error
+	if fn.kind == "constructor" {
+		result = fmt.Sprintf("wypes.Own[%s]", goName(fn.name))
+	} else {
+		result, err = g.goTypeOrVoid(fn.result)
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("(%s) %s", strings.Join(params, ", "), result), nil
+}
+
+// goTypeOrVoid is like goType but returns [wypes.Void] for a missing type.
+func (g *generator) goTypeOrVoid(typ *witType) (string, error) {
+	if typ == nil {
+		return "wypes.Void", nil
+	}
+	return g.goType(typ)
+}
+
+// goType returns the Go type for the WIT type.
+//
+// Results are represented as [wypes.Variant2] with the OK value as the first case
+// and the error as the second, so that they can be passed both through the stack
+// and through memory the same way as the canonical ABI does.
+func (g *generator) goType(typ *witType) (string, error) {
+	if typ == nil {
+		return "", fmt.Errorf("type is required")
+	}
+	if t, ok := primitives[typ.name]; ok {
+		return t, nil
+	}
+	args := make([]string, len(typ.args))
+	for i, arg := range typ.args {
+		var err error
+		args[i], err = g.goTypeOrVoid(arg)
+		if err != nil {
+			return "", err
+		}
+	}
+	switch typ.name {
+	case "list":
+		if len(args) != 1 {
+			return "", fmt.Errorf("list must have 1 type argument")
+		}
+		if args[0] == "wypes.UInt8" {
+			return "wypes.Bytes", nil
+		}
+		return fmt.Sprintf("wypes.List[%s]", args[0]), nil
+	case "option":
+		if len(args) != 1 {
+			return "", fmt.Errorf("option must have 1 type argument")
+		}
+		return fmt.Sprintf("wypes.Option[%s]", args[0]), nil
+	case "result":
+		// Not wypes.Result: it is always passed by a pointer to memory,
+		// but the canonical ABI flattens results like any other variant.
+		for len(args) < 2 {
+			args = append(args, "wypes.Void")
+		}
+		return fmt.Sprintf("wypes.Variant2[%s, %s]", args[0], args[1]), nil
+	case "tuple":
+		if len(args) < 2 || len(args) > 8 {
+			return "", fmt.Errorf("tuples must have from 2 to 8 values, got %d", len(args))
+		}
+		return fmt.Sprintf("wypes.Tuple%d[%s]", len(args), strings.Join(args, ", ")), nil
+	case "own", "borrow":
+		if len(typ.args) != 1 || typ.args[0] == nil || g.kinds[typ.args[0].name] != "resource" {
+			return "", fmt.Errorf("%s must have a resource type argument", typ.name)
+		}
+		return fmt.Sprintf("wypes.%s[%s]", goName(typ.name), goName(typ.args[0].name)), nil
+	}
+	kind, ok := g.kinds[typ.name]
+	if !ok {
+		return "", fmt.Errorf("unknown type %s", typ.name)
+	}
+	if kind == "resource" {
+		return fmt.Sprintf("wypes.Own[%s]", goName(typ.name)), nil
+	}
+	return goName(typ.name), nil
+}
+
+func (g *generator) genDocs(docs []string) {
+	for _, line := range docs {
+		if line == "" {
+			g.printf("//\n")
+		} else {
+			g.printf("// %s\n", line)
+		}
+	}
+}
+
+// moduleName returns the name of the imported module for the interface,
+// like "wasi:cli/environment@0.2.0".
+func moduleName(pkg, iface string) string {
+	if pkg == "" {
+		return iface
+	}
+	name, version, hasVersion := strings.Cut(pkg, "@")
+	name += "/" + iface
+	if hasVersion {
+		name += "@" + version
+	}
+	return name
+}
+
+// goName converts a kebab-case WIT name into an exported CamelCase Go name.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
+
+// goParamName converts a kebab-case WIT name into an unexported camelCase Go name.
+func goParamName(name string) string {
+	res := goName(name)
+	res = strings.ToLower(res[:1]) + res[1:]
+	if token.IsKeyword(res) {
+		res += "_"
+	}
+	return res
+}
+
+// dropName returns the name of the method called when the resource is dropped.
+func dropName(resource string) string {
+	return "Drop" + goName(resource)
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = fmt.Sprintf("%q", n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func namesToFields(names []string) []witField {
+	fields := make([]witField, len(names))
+	for i, n := range names {
+		fields[i] = witField{name: n}
+	}
+	return fields
+}
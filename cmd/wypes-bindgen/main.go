@@ -0,0 +1,61 @@
+// Command wypes-bindgen generates Go host bindings for WIT interfaces.
+//
+// For every interface in the given WIT file, it generates a Go interface
+// to be implemented by the host and a function that turns the implementation
+// into a [wypes.Module]:
+//
+//	wypes-bindgen -pkg bindings -o bindings.go example.wit
+//
+// The WIT file is parsed locally, dependencies are not resolved.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "wypes-bindgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("wypes-bindgen", flag.ContinueOnError)
+	pkg := flags.String("pkg", "bindings", "name of the generated Go package")
+	out := flags.String("o", "", "path to the output file, stdout if empty")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: wypes-bindgen [flags] file.wit\n")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one WIT file")
+	}
+
+	src, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	file, err := parseWIT(string(src))
+	if err != nil {
+		return fmt.Errorf("parse %s: %v", flags.Arg(0), err)
+	}
+	code, err := generate(file, *pkg)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(*out, code, 0o644)
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// witFile is a parsed WIT file.
+type witFile struct {
+	// pkg is the package name, like "example:demo@0.1.0".
+	pkg        string
+	interfaces []*witInterface
+}
+
+// witInterface is a WIT interface definition.
+type witInterface struct {
+	name  string
+	docs  []string
+	types []*witTypeDef
+	funcs []*witFunc
+}
+
+// witTypeDef is a named type defined in a WIT interface.
+type witTypeDef struct {
+	// kind is one of "record", "variant", "enum", "flags", "resource", or "type".
+	kind string
+	name string
+	docs []string
+
+	// fields are the record fields or the variant cases.
+	// The type of a variant case without a payload is nil.
+	fields []witField
+
+	// names are the enum cases or the flags.
+	names []string
+
+	// alias is the aliased type of a "type" definition.
+	alias *witType
+
+	// funcs are the constructor, methods, and static functions of a resource.
+	funcs []*witFunc
+}
+
+// witField is a named and typed record field, function param, or variant case.
+type witField struct {
+	name string
+	typ  *witType
+}
+
+// witFunc is a WIT function.
+type witFunc struct {
+	// kind is one of "func", "constructor", "method", or "static".
+	kind   string
+	name   string
+	docs   []string
+	params []witField
+	result *witType
+}
+
+// witType is a reference to a WIT type.
+//
+// For generic types, like "list" or "result", args hold the type arguments.
+// A missing type argument, like the OK type in "result<_, string>", is nil.
+type witType struct {
+	name string
+	args []*witType
+}
+
+// witToken is a lexical token of a WIT file.
+type witToken struct {
+	text string
+	// docs are the doc comments ("///") preceding the token.
+	docs []string
+	line int
+}
+
+// lex splits the WIT source into tokens, skipping whitespace and comments.
+func lex(src string) ([]witToken, error) {
+	var tokens []witToken
+	var docs []string
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end == -1 {
+				end = len(src) - i
+			}
+			comment := src[i : i+end]
+			if strings.HasPrefix(comment, "///") {
+				docs = append(docs, strings.TrimSpace(comment[3:]))
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(src[i:], "->"):
+			tokens = append(tokens, witToken{text: "->", docs: docs, line: line})
+			docs = nil
+			i += 2
+		case isIdentByte(c) || c == '%':
+			j := i + 1
+			for j < len(src) && isIdentByte(src[j]) {
+				j++
+			}
+			tokens = append(tokens, witToken{text: strings.TrimPrefix(src[i:j], "%"), docs: docs, line: line})
+			docs = nil
+			i = j
+		case strings.IndexByte("{}()<>,:;=.@/*_", c) != -1:
+			tokens = append(tokens, witToken{text: string(c), docs: docs, line: line})
+			docs = nil
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '-' || c < unicode.MaxASCII && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+// parser builds the WIT syntax tree from tokens.
+type parser struct {
+	tokens []witToken
+	pos    int
+}
+
+// parseWIT parses the source of a WIT file.
+//
+// Only the subset of WIT needed for generating host bindings is supported:
+// interfaces with functions, records, variants, enums, flags, resources, and type aliases.
+// Worlds, "use" statements, and feature gates are skipped.
+func parseWIT(src string) (*witFile, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	file := &witFile{}
+	for !p.done() {
+		tok := p.peek()
+		switch tok.text {
+		case "package":
+			p.next()
+			file.pkg, err = p.until(";")
+		case "interface":
+			var iface *witInterface
+			iface, err = p.parseInterface()
+			file.interfaces = append(file.interfaces, iface)
+		case "world":
+			p.next()
+			_, err = p.ident()
+			if err == nil {
+				err = p.skipBlock()
+			}
+		case "use":
+			_, err = p.until(";")
+		case "@":
+			err = p.skipGate()
+		default:
+			err = p.errorf("unexpected %q", tok.text)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (p *parser) parseInterface() (*witInterface, error) {
+	docs := p.next().docs
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	iface := &witInterface{name: name, docs: docs}
+	err = p.expect("{")
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text != "}" {
+		if p.done() {
+			return nil, p.errorf("unexpected end of file in interface %s", name)
+		}
+		tok := p.peek()
+		switch tok.text {
+		case "use":
+			_, err = p.until(";")
+		case "@":
+			err = p.skipGate()
+		case "record", "variant", "enum", "flags", "resource", "type":
+			var def *witTypeDef
+			def, err = p.parseTypeDef()
+			iface.types = append(iface.types, def)
+		default:
+			var fn *witFunc
+			fn, err = p.parseFunc()
+			iface.funcs = append(iface.funcs, fn)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.next()
+	return iface, checkCycles(iface)
+}
+
+// checkCycles returns an error if a type in the interface refers to itself,
+// directly or through other types. WIT doesn't allow recursive types,
+// and they can't be represented in Go as type aliases.
+func checkCycles(iface *witInterface) error {
+	defs := map[string]*witTypeDef{}
+	for _, def := range iface.types {
+		defs[def.name] = def
+	}
+	// visiting are the types on the current path, done are the types without cycles.
+	visiting := map[string]bool{}
+	done := map[string]bool{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		def, found := defs[name]
+		if !found || done[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("type %s in interface %s refers to itself", name, iface.name)
+		}
+		visiting[name] = true
+		var refs []string
+		if def.alias != nil {
+			refs = typeRefs(refs, def.alias)
+		}
+		for _, f := range def.fields {
+			refs = typeRefs(refs, f.typ)
+		}
+		for _, ref := range refs {
+			err := visit(ref)
+			if err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		done[name] = true
+		return nil
+	}
+	for _, def := range iface.types {
+		err := visit(def.name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeRefs appends the names of all the types the type reference refers to.
+func typeRefs(refs []string, typ *witType) []string {
+	if typ == nil {
+		return refs
+	}
+	refs = append(refs, typ.name)
+	for _, arg := range typ.args {
+		refs = typeRefs(refs, arg)
+	}
+	return refs
+}
+
+func (p *parser) parseTypeDef() (*witTypeDef, error) {
+	tok := p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	def := &witTypeDef{kind: tok.text, name: name, docs: tok.docs}
+	switch def.kind {
+	case "type":
+		err = p.expect("=")
+		if err != nil {
+			return nil, err
+		}
+		def.alias, err = p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return def, p.expect(";")
+	case "resource":
+		return def, p.parseResource(def)
+	}
+
+	err = p.expect("{")
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text != "}" {
+		if p.done() {
+			return nil, p.errorf("unexpected end of file in %s %s", def.kind, name)
+		}
+		if p.peek().text == "@" {
+			err = p.skipGate()
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		field, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		switch def.kind {
+		case "record":
+			err = p.expect(":")
+			if err != nil {
+				return nil, err
+			}
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			def.fields = append(def.fields, witField{name: field, typ: typ})
+		case "variant":
+			var typ *witType
+			if p.peek().text == "(" {
+				p.next()
+				typ, err = p.parseType()
+				if err != nil {
+					return nil, err
+				}
+				err = p.expect(")")
+				if err != nil {
+					return nil, err
+				}
+			}
+			def.fields = append(def.fields, witField{name: field, typ: typ})
+		default:
+			def.names = append(def.names, field)
+		}
+		if p.peek().text == "," {
+			p.next()
+		}
+	}
+	p.next()
+	return def, nil
+}
+
+func (p *parser) parseResource(def *witTypeDef) error {
+	if p.peek().text == ";" {
+		p.next()
+		return nil
+	}
+	err := p.expect("{")
+	if err != nil {
+		return err
+	}
+	for p.peek().text != "}" {
+		if p.done() {
+			return p.errorf("unexpected end of file in resource %s", def.name)
+		}
+		switch p.peek().text {
+		case "@":
+			err = p.skipGate()
+		case "constructor":
+			tok := p.next()
+			fn := &witFunc{kind: "constructor", name: def.name, docs: tok.docs}
+			fn.params, err = p.parseParams()
+			if err == nil {
+				err = p.expect(";")
+			}
+			def.funcs = append(def.funcs, fn)
+		default:
+			var fn *witFunc
+			fn, err = p.parseFunc()
+			if err == nil && fn.kind == "func" {
+				fn.kind = "method"
+			}
+			def.funcs = append(def.funcs, fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+// parseFunc parses a function like "name: func(a: u32) -> string;".
+func (p *parser) parseFunc() (*witFunc, error) {
+	docs := p.peek().docs
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	err = p.expect(":")
+	if err != nil {
+		return nil, err
+	}
+	fn := &witFunc{kind: "func", name: name, docs: docs}
+	if p.peek().text == "static" {
+		p.next()
+		fn.kind = "static"
+	}
+	err = p.expect("func")
+	if err != nil {
+		return nil, err
+	}
+	fn.params, err = p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().text == "->" {
+		p.next()
+		if p.peek().text == "(" {
+			return nil, p.errorf("named results of %s are not supported", name)
+		}
+		fn.result, err = p.parseType()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fn, p.expect(";")
+}
+
+// parseParams parses a parenthesized list of named params.
+func (p *parser) parseParams() ([]witField, error) {
+	err := p.expect("(")
+	if err != nil {
+		return nil, err
+	}
+	params := []witField{}
+	for p.peek().text != ")" {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		err = p.expect(":")
+		if err != nil {
+			return nil, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, witField{name: name, typ: typ})
+		if p.peek().text == "," {
+			p.next()
+		}
+	}
+	p.next()
+	return params, nil
+}
+
+// parseType parses a type reference, like "u32" or "result<list<u8>, string>".
+func (p *parser) parseType() (*witType, error) {
+	if p.peek().text == "_" {
+		p.next()
+		return nil, nil
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	typ := &witType{name: name}
+	if p.peek().text != "<" {
+		return typ, nil
+	}
+	p.next()
+	for p.peek().text != ">" {
+		arg, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		typ.args = append(typ.args, arg)
+		if p.peek().text == "," {
+			p.next()
+		}
+		if p.done() {
+			return nil, p.errorf("unexpected end of file in type %s", name)
+		}
+	}
+	p.next()
+	return typ, nil
+}
+
+// skipGate skips a feature gate, like "@since(version = 0.2.0)".
+func (p *parser) skipGate() error {
+	p.next()
+	_, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if p.peek().text != "(" {
+		return nil
+	}
+	_, err = p.until(")")
+	return err
+}
+
+// skipBlock skips a block in braces, including the nested blocks.
+func (p *parser) skipBlock() error {
+	err := p.expect("{")
+	if err != nil {
+		return err
+	}
+	depth := 1
+	for depth > 0 {
+		if p.done() {
+			return p.errorf("unexpected end of file")
+		}
+		switch p.next().text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+	}
+	return nil
+}
+
+// until consumes tokens up to and including the given one
+// and returns the text of the consumed tokens without the last one.
+func (p *parser) until(text string) (string, error) {
+	var b strings.Builder
+	for {
+		if p.done() {
+			return "", p.errorf("expected %q", text)
+		}
+		tok := p.next()
+		if tok.text == text {
+			return b.String(), nil
+		}
+		b.WriteString(tok.text)
+	}
+}
+
+func (p *parser) ident() (string, error) {
+	tok := p.peek()
+	if p.done() || !isIdentByte(tok.text[0]) {
+		return "", p.errorf("expected identifier, got %q", tok.text)
+	}
+	p.next()
+	return tok.text, nil
+}
+
+func (p *parser) expect(text string) error {
+	tok := p.peek()
+	if p.done() || tok.text != text {
+		return p.errorf("expected %q, got %q", text, tok.text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() witToken {
+	if p.done() {
+		return witToken{text: "EOF"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() witToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
@@ -1,10 +1,232 @@
 package wypes
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
 
 var (
 	ErrRefNotFound = errors.New("HostRef with the given ID is not found in Refs")
 	ErrMemRead     = errors.New("Memory.Read is out of bounds")
 	ErrMemWrite    = errors.New("Memory.Write is out of bounds")
 	ErrRefCast     = errors.New("Reference returned by Refs.Get is not of the type expected by HostRef")
+	ErrBorrowEnded = errors.New("Borrow is used after the host function call returned")
+	ErrDataType    = errors.New("instance data is not of the type expected by Data")
+
+	ErrNoAllocator  = errors.New("guest module does not export an allocator")
+	ErrAlloc        = errors.New("guest allocator failed to allocate memory")
+	ErrDiscriminant = errors.New("discriminant is out of range")
+	ErrSpill        = errors.New("value cannot be passed through memory")
+
+	ErrExportNotFound  = errors.New("function is not exported by the guest module")
+	ErrSignature       = errors.New("function signature does not match")
+	ErrNotImplemented  = errors.New("not implemented")
+	ErrCanceled        = errors.New("host function call is canceled")
+	ErrModuleClosed    = errors.New("guest module is closed")
+	ErrNotInstantiated = errors.New("guest module is not instantiated with WazeroInstances.Instantiate")
+
+	ErrStub = errors.New("host function cannot be declared in the guest language")
+
+	ErrInvalidWasm   = errors.New("invalid wasm binary")
+	ErrModuleMissing = errors.New("module imported by the guest is not defined")
+	ErrFuncMissing   = errors.New("function imported by the guest is not defined")
+	ErrFuncUnused    = errors.New("host function is not imported by the guest")
 )
+
+// LiftError is an error that happened when lifting a host function parameter
+// or a guest function result.
+//
+// Use [errors.Is] to check for the underlying error, like [ErrMemRead].
+type LiftError struct {
+	// Module is the name of the host module.
+	Module string
+
+	// Function is the name of the host function or of the guest function.
+	Function string
+
+	// Param is the index of the host function parameter.
+	//
+	// If Guest is set, it is the index of the guest function result.
+	Param int
+
+	// Guest is set if the error happened when calling a guest function with [G0] to [G18].
+	Guest bool
+
+	// Type is the wypes type that failed to lift.
+	//
+	// For container types, like [List], it is the type of the failed element.
+	Type Value
+
+	// Addr is the start of the offending memory range, if the error is memory-related.
+	Addr Addr
+
+	// Len is the length of the offending memory range, if the error is memory-related.
+	Len uint32
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LiftError) Error() string {
+	op := "lift param"
+	if e.Guest {
+		op = "lift result"
+	}
+	return formatCallError(op, e.Param, e.Type, e.Module, e.Function, e.Addr, e.Len, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *LiftError) Unwrap() error {
+	return e.Err
+}
+
+// LowerError is an error that happened when lowering a host function result
+// or a guest function argument.
+//
+// Use [errors.Is] to check for the underlying error, like [ErrMemWrite].
+type LowerError struct {
+	// Module is the name of the host module.
+	Module string
+
+	// Function is the name of the host function or of the guest function.
+	Function string
+
+	// Result is the index of the host function result.
+	//
+	// If Guest is set, it is the index of the guest function argument.
+	Result int
+
+	// Guest is set if the error happened when calling a guest function with [G0] to [G18].
+	Guest bool
+
+	// Type is the wypes type that failed to lower.
+	//
+	// For container types, like [List], it is the type of the failed element.
+	Type Value
+
+	// Addr is the start of the offending memory range, if the error is memory-related.
+	Addr Addr
+
+	// Len is the length of the offending memory range, if the error is memory-related.
+	Len uint32
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LowerError) Error() string {
+	op := "lower result"
+	if e.Guest {
+		op = "lower argument"
+	}
+	return formatCallError(op, e.Result, e.Type, e.Module, e.Function, e.Addr, e.Len, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *LowerError) Unwrap() error {
+	return e.Err
+}
+
+// HostError is an error returned by a host function defined with [H0E] to [H18E].
+type HostError struct {
+	// Module is the name of the host module.
+	Module string
+
+	// Function is the name of the host function.
+	Function string
+
+	// Err is the error returned by the host function.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *HostError) Error() string {
+	if e.Module == "" && e.Function == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s.%s: %v", e.Module, e.Function, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HostError) Unwrap() error {
+	return e.Err
+}
+
+// HostPanicError is a panic that happened in a host function
+// or in [Lift] or [Lower] of its parameters and results.
+//
+// See [HostFunc.Recover].
+type HostPanicError struct {
+	// Module is the name of the host module.
+	Module string
+
+	// Function is the name of the host function.
+	Function string
+
+	// Value is the value passed into panic.
+	Value any
+
+	// Stack is the Go stack trace of the goroutine at the moment of the panic.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *HostPanicError) Error() string {
+	if e.Module == "" && e.Function == "" {
+		return fmt.Sprintf("host function panicked: %v", e.Value)
+	}
+	return fmt.Sprintf("%s.%s panicked: %v", e.Module, e.Function, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *HostPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func formatCallError(
+	op string, idx int, typ Value,
+	modName, funcName string,
+	addr Addr, size uint32, err error,
+) string {
+	msg := fmt.Sprintf("%s #%d (%T)", op, idx, typ)
+	if modName != "" || funcName != "" {
+		msg += fmt.Sprintf(" of %s.%s", modName, funcName)
+	}
+	msg += fmt.Sprintf(": %v", err)
+	if addr != 0 || size != 0 {
+		msg += fmt.Sprintf(" (addr %#x, len %d)", addr, size)
+	}
+	return msg
+}
+
+// ErrorPolicy decides what happens when a host-defined function fails.
+//
+// It is called with the error recorded in [Store.Error] after the host function call.
+// If the policy returns a non-nil error, the guest is trapped with that error.
+// If it returns nil, the error is discarded and the guest continues the execution.
+type ErrorPolicy func(ctx context.Context, err error) error
+
+// TrapOnError is an [ErrorPolicy] that traps the guest on every error.
+//
+// It is the default policy.
+func TrapOnError(ctx context.Context, err error) error {
+	return err
+}
+
+// LogOnError is an [ErrorPolicy] that logs every error and lets the guest continue.
+//
+// If logger is nil, [slog.Default] is used.
+func LogOnError(logger *slog.Logger) ErrorPolicy {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(ctx context.Context, err error) error {
+		logger.ErrorContext(ctx, "host function failed", "error", err)
+		return nil
+	}
+}
@@ -0,0 +1,839 @@
+//go:build !nowazero
+// +build !nowazero
+
+package wypes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// GuestOption configures a guest-defined function wrapped by [G0] to [G18].
+type GuestOption func(*wazeroGuest)
+
+// WithGuestRefs sets the [Refs] used to lower [HostRef] arguments
+// and to lift [HostRef] results of the guest function.
+//
+// Pass the same Refs as into [Modules.DefineWazero], so that the guest
+// can pass the references it got from the host into host functions.
+// By default, each wrapped function has its own [MapRefs].
+func WithGuestRefs(refs Refs) GuestOption {
+	return func(g *wazeroGuest) {
+		g.refs = refs
+	}
+}
+
+// wazeroGuest is a guest-defined function wrapped by [G0] to [G18].
+type wazeroGuest struct {
+	mod  api.Module
+	fn   api.Function
+	name string
+	refs Refs
+
+	// size is how many values the stack must fit to hold both params and results.
+	size       int
+	numResults int
+}
+
+// wazeroGuestFunc finds the guest function with the given name
+// and checks that its signature matches the given params and results.
+func wazeroGuestFunc(mod api.Module, name string, params, results []Value, opts []GuestOption) (*wazeroGuest, error) {
+	fn := mod.ExportedFunction(name)
+	if fn == nil {
+		return nil, fmt.Errorf("%w: %s", ErrExportNotFound, name)
+	}
+	def := fn.Definition()
+	paramTypes := mergeValueTypes(params)
+	resultTypes := mergeValueTypes(results)
+	if !equalValueTypes(def.ParamTypes(), paramTypes) || !equalValueTypes(def.ResultTypes(), resultTypes) {
+		return nil, fmt.Errorf(
+			"%w: %s: expected %s, got %s",
+			ErrSignature, name,
+			formatSignature(paramTypes, resultTypes),
+			formatSignature(def.ParamTypes(), def.ResultTypes()),
+		)
+	}
+	g := &wazeroGuest{
+		mod:        mod,
+		fn:         fn,
+		name:       name,
+		size:       max(len(paramTypes), len(resultTypes)),
+		numResults: len(resultTypes),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.refs == nil {
+		g.refs = NewMapRefs()
+	}
+	return g, nil
+}
+
+// store creates a new [Store] to lower the function arguments into.
+func (g *wazeroGuest) store(ctx context.Context) *Store {
+	stack := make(SliceStack, 0, g.size)
+	return &Store{
+		Stack:     &stack,
+		Memory:    wazeroMemoryOf(g.mod),
+		Refs:      g.refs,
+		Allocator: wazeroAllocator{mod: g.mod},
+		Context:   ctx,
+		FuncName:  g.name,
+		guest:     true,
+	}
+}
+
+// lowerArg lowers the guest function argument with the given index.
+func lowerArg[T Lower](s *Store, v T, idx int) {
+	s.index = idx
+	v.Lower(s)
+}
+
+// liftResult lifts the guest function result.
+func liftResult[T Lift[T]](s *Store, v T) T {
+	s.index = 0
+	res := v.Lift(s)
+	s.commitLift()
+	return res
+}
+
+// call calls the guest function with the arguments lowered into the [Store].
+//
+// After the call, the stack contains the function results.
+func (g *wazeroGuest) call(s *Store) error {
+	if s.Error != nil {
+		return s.Error
+	}
+	stack := s.Stack.(*SliceStack)
+	buf := (*stack)[:g.size]
+	err := g.fn.CallWithStack(s.Context, buf)
+	if err != nil {
+		return err
+	}
+	*stack = buf[:g.numResults]
+	return nil
+}
+
+// G0 wraps a guest-defined function that accepts no arguments.
+func G0[Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context) (Z, error), error) {
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (Z, error) {
+		s := fn.store(ctx)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G1 wraps a guest-defined function that accepts 1 high-level argument.
+func G1[A Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A) (Z, error), error) {
+	var a A
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G2 wraps a guest-defined function that accepts 2 high-level arguments.
+func G2[A Lower, B Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B) (Z, error), error) {
+	var a A
+	var b B
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G3 wraps a guest-defined function that accepts 3 high-level arguments.
+func G3[A Lower, B Lower, C Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G4 wraps a guest-defined function that accepts 4 high-level arguments.
+func G4[A Lower, B Lower, C Lower, D Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G5 wraps a guest-defined function that accepts 5 high-level arguments.
+func G5[A Lower, B Lower, C Lower, D Lower, E Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G6 wraps a guest-defined function that accepts 6 high-level arguments.
+func G6[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G7 wraps a guest-defined function that accepts 7 high-level arguments.
+func G7[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G8 wraps a guest-defined function that accepts 8 high-level arguments.
+func G8[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G9 wraps a guest-defined function that accepts 9 high-level arguments.
+func G9[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G10 wraps a guest-defined function that accepts 10 high-level arguments.
+func G10[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G11 wraps a guest-defined function that accepts 11 high-level arguments.
+func G11[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G12 wraps a guest-defined function that accepts 12 high-level arguments.
+func G12[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G13 wraps a guest-defined function that accepts 13 high-level arguments.
+func G13[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, M Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L, M) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L, m M) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		lowerArg(s, m, 12)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G14 wraps a guest-defined function that accepts 14 high-level arguments.
+func G14[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, M Lower, N Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L, M, N) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L, m M, n N) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		lowerArg(s, m, 12)
+		lowerArg(s, n, 13)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G15 wraps a guest-defined function that accepts 15 high-level arguments.
+func G15[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, M Lower, N Lower, O Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L, M, N, O) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L, m M, n N, o O) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		lowerArg(s, m, 12)
+		lowerArg(s, n, 13)
+		lowerArg(s, o, 14)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G16 wraps a guest-defined function that accepts 16 high-level arguments.
+func G16[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, M Lower, N Lower, O Lower, P Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var p P
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L, m M, n N, o O, p P) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		lowerArg(s, m, 12)
+		lowerArg(s, n, 13)
+		lowerArg(s, o, 14)
+		lowerArg(s, p, 15)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G17 wraps a guest-defined function that accepts 17 high-level arguments.
+func G17[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, M Lower, N Lower, O Lower, P Lower, Q Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P, Q) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var p P
+	var q Q
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L, m M, n N, o O, p P, q Q) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		lowerArg(s, m, 12)
+		lowerArg(s, n, 13)
+		lowerArg(s, o, 14)
+		lowerArg(s, p, 15)
+		lowerArg(s, q, 16)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
+
+// G18 wraps a guest-defined function that accepts 18 high-level arguments.
+func G18[A Lower, B Lower, C Lower, D Lower, E Lower, F Lower, G Lower, H Lower, I Lower, J Lower, K Lower, L Lower, M Lower, N Lower, O Lower, P Lower, Q Lower, R Lower, Z Lift[Z]](
+	mod api.Module, name string, opts ...GuestOption,
+) (func(context.Context, A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P, Q, R) (Z, error), error) {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var p P
+	var q Q
+	var r R
+	var z Z
+	fn, err := wazeroGuestFunc(mod, name, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r}, []Value{z}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, a A, b B, c C, d D, e E, f F, g G, h H, i I, j J, k K, l L, m M, n N, o O, p P, q Q, r R) (Z, error) {
+		s := fn.store(ctx)
+		lowerArg(s, a, 0)
+		lowerArg(s, b, 1)
+		lowerArg(s, c, 2)
+		lowerArg(s, d, 3)
+		lowerArg(s, e, 4)
+		lowerArg(s, f, 5)
+		lowerArg(s, g, 6)
+		lowerArg(s, h, 7)
+		lowerArg(s, i, 8)
+		lowerArg(s, j, 9)
+		lowerArg(s, k, 10)
+		lowerArg(s, l, 11)
+		lowerArg(s, m, 12)
+		lowerArg(s, n, 13)
+		lowerArg(s, o, 14)
+		lowerArg(s, p, 15)
+		lowerArg(s, q, 16)
+		lowerArg(s, r, 17)
+		err := fn.call(s)
+		if err != nil {
+			return z, err
+		}
+		res := liftResult(s, z)
+		return res, s.Error
+	}, nil
+}
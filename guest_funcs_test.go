@@ -0,0 +1,138 @@
+//go:build !nowazero
+// +build !nowazero
+
+package wypes_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func TestG2(t *testing.T) {
+	c := is.NewRelaxed(t)
+	add := wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b })
+	mod := instantiateGuest(t, add)
+	run, err := wypes.G2[wypes.Int32, wypes.Int32, wypes.Int32](mod, "run")
+	is.Err(is.Not(c), err)
+	res, err := run(context.Background(), 3, -5)
+	is.Err(is.Not(c), err)
+	is.Equal(c, res, -2)
+}
+
+func TestG0_Void(t *testing.T) {
+	c := is.NewRelaxed(t)
+	called := false
+	f := wypes.H0(func() wypes.Void {
+		called = true
+		return wypes.Void{}
+	})
+	mod := instantiateGuest(t, f)
+	run, err := wypes.G0[wypes.Void](mod, "run")
+	is.Err(is.Not(c), err)
+	_, err = run(context.Background())
+	is.Err(is.Not(c), err)
+	is.True(c, called)
+}
+
+func TestG_Validate(t *testing.T) {
+	c := is.NewRelaxed(t)
+	add := wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b })
+	mod := instantiateGuest(t, add)
+
+	_, err := wypes.G1[wypes.Int32, wypes.Int32](mod, "run")
+	is.True(c, errors.Is(err, wypes.ErrSignature))
+
+	_, err = wypes.G2[wypes.Int32, wypes.Int32, wypes.Int64](mod, "run")
+	is.True(c, errors.Is(err, wypes.ErrSignature))
+
+	_, err = wypes.G0[wypes.Void](mod, "walk")
+	is.True(c, errors.Is(err, wypes.ErrExportNotFound))
+}
+
+func TestG1_String(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var got string
+	f := wypes.H1(func(s wypes.String) wypes.Void {
+		got = s.Unwrap()
+		return wypes.Void{}
+	})
+	malloc := wasmFunc{
+		name:    "malloc",
+		params:  []wypes.ValueType{wypes.ValueTypeI32},
+		results: []wypes.ValueType{wypes.ValueTypeI32},
+		body:    []byte{0x41, 0x80, 0x02, 0x0b}, // i32.const 256, end
+	}
+	guest := wasmGuest("env", "f", f.ParamValueTypes(), f.ResultValueTypes(), malloc)
+	mod := instantiate(t, f, guest)
+	run, err := wypes.G1[wypes.String, wypes.Void](mod, "run")
+	is.Err(is.Not(c), err)
+	_, err = run(context.Background(), wypes.String{Raw: "hello"})
+	is.Err(is.Not(c), err)
+	is.Equal(c, got, "hello")
+	// the string is written into the memory allocated by the guest
+	data, _ := mod.Memory().Read(256, 5)
+	is.Equal(c, string(data), "hello")
+	zeros, _ := mod.Memory().Read(0, 5)
+	is.SliceEqual(c, zeros, []byte{0, 0, 0, 0, 0})
+}
+
+func TestG1_String_NoAllocator(t *testing.T) {
+	c := is.NewRelaxed(t)
+	called := false
+	f := wypes.H1(func(s wypes.String) wypes.Void {
+		called = true
+		return wypes.Void{}
+	})
+	mod := instantiateGuest(t, f)
+	run, err := wypes.G1[wypes.String, wypes.Void](mod, "run")
+	is.Err(is.Not(c), err)
+	_, err = run(context.Background(), wypes.String{Raw: "hello"})
+	is.True(c, errors.Is(err, wypes.ErrNoAllocator))
+	is.True(c, !called)
+}
+
+func TestG2_LowerError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H2(func(a wypes.Int32, s wypes.String) wypes.Void { return wypes.Void{} })
+	mod := instantiateGuest(t, f)
+	run, err := wypes.G2[wypes.Int32, wypes.String, wypes.Void](mod, "run")
+	is.Err(is.Not(c), err)
+	_, err = run(context.Background(), 1, wypes.String{Raw: "hello"})
+	var lowerErr *wypes.LowerError
+	is.True(c, errors.As(err, &lowerErr))
+	is.True(c, lowerErr.Guest)
+	is.Equal(c, lowerErr.Result, 1)
+	is.True(c, strings.HasPrefix(err.Error(), "lower argument #1 "))
+}
+
+func TestG1_HostRef(t *testing.T) {
+	c := is.NewRelaxed(t)
+	refs := wypes.NewMapRefs()
+	f := wypes.H1(func(r wypes.HostRef[string]) wypes.Int32 {
+		return wypes.Int32(len(r.Unwrap()))
+	})
+	guest := wasmGuest("env", "f", f.ParamValueTypes(), f.ResultValueTypes())
+	mod := instantiateWithRefs(t, f, guest, refs)
+	run, err := wypes.G1[wypes.HostRef[string], wypes.Int32](mod, "run", wypes.WithGuestRefs(refs))
+	is.Err(is.Not(c), err)
+	res, err := run(context.Background(), wypes.HostRef[string]{Raw: "hello"})
+	is.Err(is.Not(c), err)
+	is.Equal(c, res, 5)
+	is.Equal(c, len(refs.Raw), 1)
+}
+
+func TestG1_HostRef_DefaultRefs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H1(func(r wypes.HostRef[string]) wypes.Int32 { return 0 })
+	mod := instantiateGuest(t, f)
+	run, err := wypes.G1[wypes.HostRef[string], wypes.Int32](mod, "run")
+	is.Err(is.Not(c), err)
+	// lowering doesn't panic without explicit refs
+	_, err = run(context.Background(), wypes.HostRef[string]{Raw: "hello"})
+	is.True(c, errors.Is(err, wypes.ErrRefNotFound))
+}
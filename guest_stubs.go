@@ -0,0 +1,341 @@
+package wypes
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GuestLang is the language of the guest stubs generated by [Modules.WriteGuestStubs].
+type GuestLang int
+
+const (
+	// GuestTinyGo generates "//go:wasmimport" declarations for TinyGo.
+	//
+	// Since TinyGo supports strings and booleans in imports, they are used for [String] and [Bool].
+	GuestTinyGo GuestLang = iota
+
+	// GuestGo generates "//go:wasmimport" declarations for Go with GOOS=wasip1.
+	GuestGo
+
+	// GuestC generates a C header with functions imported using clang attributes.
+	GuestC
+
+	// GuestRust generates Rust "extern" blocks.
+	GuestRust
+)
+
+// WriteGuestStubs writes declarations of all the host functions for a guest module
+// written in the given language.
+//
+// The signatures are based on [HostFunc.ParamValueTypes] and [HostFunc.ResultValueTypes],
+// so they always match what the host expects. The concrete wypes types are used to pick
+// better types, like unsigned integers for [UInt32]. Params of memory-based types,
+// like [String], are split into the pointer and the length.
+func (ms Modules) WriteGuestStubs(w io.Writer, lang GuestLang) error {
+	stubs, err := ms.guestStubs(lang)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	switch lang {
+	case GuestTinyGo, GuestGo:
+		writeGoStubs(&b, stubs, lang)
+	case GuestC:
+		writeCStubs(&b, stubs)
+	case GuestRust:
+		writeRustStubs(&b, stubs)
+	default:
+		return fmt.Errorf("unknown guest language: %d", lang)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// guestStub is a declaration of a host function for the guest.
+type guestStub struct {
+	module string
+	name   string
+	ident  string
+	params []stubValue
+	result *stubValue
+}
+
+// stubValue is a param or a result of a guest stub.
+type stubValue struct {
+	name string
+	kind stubKind
+}
+
+// stubKind is the type of a stub value independent of the guest language.
+type stubKind int
+
+const (
+	stubI32 stubKind = iota
+	stubI64
+	stubU32
+	stubU64
+	stubF32
+	stubF64
+	stubBool
+	stubString
+)
+
+// guestStubs returns the stubs for all the host functions sorted by module and function names.
+func (ms Modules) guestStubs(lang GuestLang) ([]guestStub, error) {
+	var stubs []guestStub
+	idents := map[string]int{}
+	for _, modName := range sortedKeys(ms) {
+		m := ms[modName]
+		for _, funcName := range sortedKeys(m) {
+			f := m[funcName]
+			stub, err := newGuestStub(modName, funcName, f, lang)
+			if err != nil {
+				return nil, err
+			}
+			idents[stub.ident]++
+			stubs = append(stubs, stub)
+		}
+	}
+	// functions with the same name in different modules are prefixed with the module name
+	for i, stub := range stubs {
+		if idents[stub.ident] > 1 {
+			stubs[i].ident = stubIdent(stub.module+"-"+stub.name, lang)
+		}
+	}
+	return stubs, nil
+}
+
+func newGuestStub(modName, funcName string, f HostFunc, lang GuestLang) (guestStub, error) {
+	stub := guestStub{
+		module: modName,
+		name:   funcName,
+		ident:  stubIdent(funcName, lang),
+	}
+	if f.spillParams {
+		stub.params = append(stub.params, stubValue{name: stubName(lang, "params"), kind: stubU32})
+	} else {
+		for i, p := range f.Params {
+			stub.params = append(stub.params, stubValues(p, i, lang)...)
+		}
+	}
+	if f.spillResults {
+		stub.params = append(stub.params, stubValue{name: stubName(lang, "retptr"), kind: stubU32})
+		return stub, nil
+	}
+	results := []stubValue{}
+	for i, r := range f.Results {
+		if _, ok := r.(outResult); ok {
+			continue
+		}
+		results = append(results, stubValues(r, i, lang)...)
+	}
+	switch {
+	case len(results) == 1 && results[0].kind != stubString:
+		stub.result = &results[0]
+	case len(results) > 0:
+		return stub, fmt.Errorf("%w: %s.%s has %d results", ErrStub, modName, funcName, f.NumResults())
+	}
+	return stub, nil
+}
+
+// hostRefValue is implemented by [HostRef] of any type.
+type hostRefValue interface {
+	isHostRef()
+}
+
+// stubValues returns the stub values for the param or result with the given index.
+func stubValues(v Value, idx int, lang GuestLang) []stubValue {
+	name := fmt.Sprintf("p%d", idx)
+	switch v.(type) {
+	case Int8, Int16, Int32:
+		return []stubValue{{name: name, kind: stubI32}}
+	case UInt8, UInt16, UInt32, hostRefValue:
+		return []stubValue{{name: name, kind: stubU32}}
+	case Int64, Int:
+		return []stubValue{{name: name, kind: stubI64}}
+	case UInt64, UInt, UIntPtr:
+		return []stubValue{{name: name, kind: stubU64}}
+	case Bool:
+		if lang == GuestGo {
+			return []stubValue{{name: name, kind: stubU32}}
+		}
+		return []stubValue{{name: name, kind: stubBool}}
+	case String:
+		if lang == GuestTinyGo {
+			return []stubValue{{name: name, kind: stubString}}
+		}
+		return []stubValue{
+			{name: stubName(lang, name, "ptr"), kind: stubU32},
+			{name: stubName(lang, name, "len"), kind: stubU32},
+		}
+	case Bytes, ListStrings:
+		return []stubValue{
+			{name: stubName(lang, name, "ptr"), kind: stubU32},
+			{name: stubName(lang, name, "len"), kind: stubU32},
+		}
+	}
+	types := v.ValueTypes()
+	values := make([]stubValue, len(types))
+	for i, t := range types {
+		values[i].name = name
+		if len(types) > 1 {
+			values[i].name = stubName(lang, name, fmt.Sprint(i))
+		}
+		switch t {
+		case ValueTypeI64:
+			values[i].kind = stubI64
+		case ValueTypeF32:
+			values[i].kind = stubF32
+		case ValueTypeF64:
+			values[i].kind = stubF64
+		default:
+			values[i].kind = stubI32
+		}
+	}
+	return values
+}
+
+// stubName joins the parts of a name in the naming style of the language.
+func stubName(lang GuestLang, parts ...string) string {
+	if lang == GuestC || lang == GuestRust {
+		return strings.Join(parts, "_")
+	}
+	name := parts[0]
+	for _, part := range parts[1:] {
+		name += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return name
+}
+
+// stubIdent converts a host function name into a valid identifier in the guest language.
+//
+// For example, "[method]file.read" becomes "methodFileRead" in Go and "method_file_read" in C.
+func stubIdent(name string, lang GuestLang) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+	if len(parts) == 0 {
+		parts = []string{"f"}
+	}
+	if '0' <= parts[0][0] && parts[0][0] <= '9' {
+		parts[0] = "f" + parts[0]
+	}
+	ident := stubName(lang, parts...)
+	if isReserved(ident, lang) {
+		ident += "_"
+	}
+	return ident
+}
+
+// isReserved returns true if the identifier is a keyword in the guest language.
+func isReserved(ident string, lang GuestLang) bool {
+	switch lang {
+	case GuestTinyGo, GuestGo:
+		return token.IsKeyword(ident)
+	case GuestC:
+		switch ident {
+		case "auto", "break", "case", "char", "const", "continue", "default", "do",
+			"double", "else", "enum", "extern", "float", "for", "goto", "if", "int",
+			"long", "register", "return", "short", "signed", "sizeof", "static",
+			"struct", "switch", "typedef", "union", "unsigned", "void", "volatile", "while":
+			return true
+		}
+	case GuestRust:
+		switch ident {
+		case "as", "break", "const", "continue", "crate", "else", "enum", "extern",
+			"false", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod",
+			"move", "mut", "pub", "ref", "return", "self", "static", "struct", "super",
+			"trait", "true", "type", "unsafe", "use", "where", "while":
+			return true
+		}
+	}
+	return false
+}
+
+func writeGoStubs(w *strings.Builder, stubs []guestStub, lang GuestLang) {
+	w.WriteString("// Code generated by wypes. DO NOT EDIT.\n\n")
+	w.WriteString("package main\n")
+	for _, stub := range stubs {
+		params := make([]string, len(stub.params))
+		for i, p := range stub.params {
+			params[i] = p.name + " " + goStubType(p.kind)
+		}
+		result := ""
+		if stub.result != nil {
+			result = " " + goStubType(stub.result.kind)
+		}
+		fmt.Fprintf(w, "\n//go:wasmimport %s %s\n", stub.module, stub.name)
+		fmt.Fprintf(w, "func %s(%s)%s\n", stub.ident, strings.Join(params, ", "), result)
+	}
+}
+
+func goStubType(kind stubKind) string {
+	return [...]string{"int32", "int64", "uint32", "uint64", "float32", "float64", "bool", "string"}[kind]
+}
+
+func writeCStubs(w *strings.Builder, stubs []guestStub) {
+	w.WriteString("// Code generated by wypes. DO NOT EDIT.\n\n")
+	w.WriteString("#pragma once\n\n")
+	w.WriteString("#include <stdbool.h>\n")
+	w.WriteString("#include <stdint.h>\n")
+	for _, stub := range stubs {
+		params := make([]string, len(stub.params))
+		for i, p := range stub.params {
+			params[i] = cStubType(p.kind) + " " + p.name
+		}
+		if len(params) == 0 {
+			params = []string{"void"}
+		}
+		result := "void"
+		if stub.result != nil {
+			result = cStubType(stub.result.kind)
+		}
+		fmt.Fprintf(w, "\n__attribute__((import_module(%q), import_name(%q)))\n", stub.module, stub.name)
+		fmt.Fprintf(w, "%s %s(%s);\n", result, stub.ident, strings.Join(params, ", "))
+	}
+}
+
+func cStubType(kind stubKind) string {
+	return [...]string{"int32_t", "int64_t", "uint32_t", "uint64_t", "float", "double", "bool", ""}[kind]
+}
+
+func writeRustStubs(w *strings.Builder, stubs []guestStub) {
+	w.WriteString("// Code generated by wypes. DO NOT EDIT.\n")
+	for i, stub := range stubs {
+		if i == 0 || stubs[i-1].module != stub.module {
+			fmt.Fprintf(w, "\n#[link(wasm_import_module = %q)]\n", stub.module)
+			w.WriteString("extern \"C\" {\n")
+		}
+		params := make([]string, len(stub.params))
+		for i, p := range stub.params {
+			params[i] = p.name + ": " + rustStubType(p.kind)
+		}
+		result := ""
+		if stub.result != nil {
+			result = " -> " + rustStubType(stub.result.kind)
+		}
+		if stub.ident != stub.name {
+			fmt.Fprintf(w, "    #[link_name = %q]\n", stub.name)
+		}
+		fmt.Fprintf(w, "    pub fn %s(%s)%s;\n", stub.ident, strings.Join(params, ", "), result)
+		if i == len(stubs)-1 || stubs[i+1].module != stub.module {
+			w.WriteString("}\n")
+		}
+	}
+}
+
+func rustStubType(kind stubKind) string {
+	return [...]string{"i32", "i64", "u32", "u64", "f32", "f64", "bool", ""}[kind]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package wypes_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func stubModules() wypes.Modules {
+	return wypes.Modules{
+		"env": {
+			"add_i32": wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b }),
+			"print":   wypes.H1(func(s wypes.String) wypes.Void { return wypes.Void{} }),
+		},
+		"math": {
+			"add_i32": wypes.H2(func(a, b wypes.UInt64) wypes.UInt64 { return a + b }),
+			"[method]file.read": wypes.H2(func(f wypes.UInt32, b wypes.Bool) wypes.Float64 {
+				return 0
+			}),
+		},
+	}
+}
+
+func TestWriteGuestStubs_TinyGo(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var b strings.Builder
+	err := stubModules().WriteGuestStubs(&b, wypes.GuestTinyGo)
+	is.Err(is.Not(c), err)
+	out := b.String()
+	is.True(c, strings.Contains(out, "//go:wasmimport env add_i32\nfunc envAddI32(p0 int32, p1 int32) int32\n"))
+	is.True(c, strings.Contains(out, "//go:wasmimport env print\nfunc print(p0 string)\n"))
+	is.True(c, strings.Contains(out, "//go:wasmimport math add_i32\nfunc mathAddI32(p0 uint64, p1 uint64) uint64\n"))
+	is.True(c, strings.Contains(out, "//go:wasmimport math [method]file.read\nfunc methodFileRead(p0 uint32, p1 bool) float64\n"))
+}
+
+func TestWriteGuestStubs_HostRef(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ms := wypes.Modules{
+		"env": {
+			"close": wypes.H1(func(r wypes.HostRef[string]) wypes.Void { return wypes.Void{} }),
+		},
+	}
+	var b strings.Builder
+	err := ms.WriteGuestStubs(&b, wypes.GuestC)
+	is.Err(is.Not(c), err)
+	is.True(c, strings.Contains(b.String(), "void close(uint32_t p0);\n"))
+}
+
+func TestWriteGuestStubs_Go(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var b strings.Builder
+	err := stubModules().WriteGuestStubs(&b, wypes.GuestGo)
+	is.Err(is.Not(c), err)
+	out := b.String()
+	is.True(c, strings.Contains(out, "func print(p0Ptr uint32, p0Len uint32)\n"))
+	is.True(c, strings.Contains(out, "func methodFileRead(p0 uint32, p1 uint32) float64\n"))
+}
+
+func TestWriteGuestStubs_C(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var b strings.Builder
+	err := stubModules().WriteGuestStubs(&b, wypes.GuestC)
+	is.Err(is.Not(c), err)
+	out := b.String()
+	is.True(c, strings.Contains(out, "__attribute__((import_module(\"env\"), import_name(\"print\")))\nvoid print(uint32_t p0_ptr, uint32_t p0_len);\n"))
+	is.True(c, strings.Contains(out, "int32_t env_add_i32(int32_t p0, int32_t p1);\n"))
+	is.True(c, strings.Contains(out, "double method_file_read(uint32_t p0, bool p1);\n"))
+}
+
+func TestWriteGuestStubs_Rust(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var b strings.Builder
+	err := stubModules().WriteGuestStubs(&b, wypes.GuestRust)
+	is.Err(is.Not(c), err)
+	out := b.String()
+	is.True(c, strings.Contains(out, "#[link(wasm_import_module = \"env\")]\nextern \"C\" {\n"))
+	is.True(c, strings.Contains(out, "    #[link_name = \"add_i32\"]\n    pub fn env_add_i32(p0: i32, p1: i32) -> i32;\n"))
+	is.True(c, strings.Contains(out, "    pub fn print(p0_ptr: u32, p0_len: u32);\n}\n"))
+	is.True(c, strings.Contains(out, "    #[link_name = \"[method]file.read\"]\n    pub fn method_file_read(p0: u32, p1: bool) -> f64;\n"))
+}
+
+func TestWriteGuestStubs_Spill(t *testing.T) {
+	c := is.NewRelaxed(t)
+	mods := wypes.Modules{"env": {
+		"greet": wypes.H0(func() wypes.String { return wypes.String{} }),
+	}}
+	var b strings.Builder
+	err := mods.WriteGuestStubs(&b, wypes.GuestC)
+	is.Err(is.Not(c), err)
+	is.True(c, strings.Contains(b.String(), "void greet(uint32_t retptr);\n"))
+}
+
+func TestWriteGuestStubs_MultiResult(t *testing.T) {
+	c := is.NewRelaxed(t)
+	mods := wypes.Modules{"env": {
+		"pair": wypes.H0(func() wypes.Pair[wypes.Int32, wypes.Int32] {
+			return wypes.Pair[wypes.Int32, wypes.Int32]{}
+		}),
+	}}
+	var b strings.Builder
+	err := mods.WriteGuestStubs(&b, wypes.GuestRust)
+	is.True(c, errors.Is(err, wypes.ErrStub))
+}
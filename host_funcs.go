@@ -1,33 +1,279 @@
 package wypes
 
+import (
+	"errors"
+	"runtime/debug"
+)
+
 // HostFunc is a wrapped host-defined function.
 //
 // It is constructed with functions from [H0] to [H8] where the number is
 // how many arguments it accepts. If you need more, use [Pair].
 //
 // There is always exactly one result. If you need to return nothing, use [Void].
+// A [Result] is written into memory at its Offset, so nothing is returned to the guest.
 // If you want to return 2 or more values, use [Pair], but make sure that the guest
 // and the runtime support multi-value returns.
+//
+// Same as in the component model canonical ABI, if the flattened params don't fit
+// into 16 values and all of them can be read from memory, they are passed through memory
+// instead: the params are read from a tuple pointed by the only i32 param.
+// If the flattened results don't fit into 1 value and all of them can be written into memory,
+// they are written into the return area pointed by an extra i32 param at the end.
+// Use [HostFunc.FlatResults] to return them as multiple values instead.
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
 type HostFunc struct {
 	Params  []Value
 	Results []Value
 	Call    func(*Store)
+
+	// spillParams is true if the params are passed through memory.
+	spillParams bool
+
+	// spillResults is true if the results are passed through memory.
+	spillResults bool
 }
 
 func (f *HostFunc) NumParams() int {
-	return countStackValues(f.Params)
+	return len(f.ParamValueTypes())
 }
 
 func (f *HostFunc) NumResults() int {
-	return countStackValues(f.Results)
+	return len(f.ResultValueTypes())
 }
 
 func (f *HostFunc) ParamValueTypes() []ValueType {
-	return mergeValueTypes(f.Params)
+	types := []ValueType{ValueTypeI32}
+	if !f.spillParams {
+		types = mergeValueTypes(f.Params)
+	}
+	if f.spillResults {
+		types = append(types, ValueTypeI32)
+	}
+	return types
 }
 
 func (f *HostFunc) ResultValueTypes() []ValueType {
-	return mergeValueTypes(f.Results)
+	if f.spillResults {
+		return []ValueType{}
+	}
+	res := make([]ValueType, 0, len(f.Results))
+	for _, v := range f.Results {
+		res = append(res, resultValueTypes(v)...)
+	}
+	return res
+}
+
+// outResult is implemented by results, like [Result], that are written into memory
+// at the address passed by the guest as a param. They return nothing to the guest.
+type outResult interface {
+	isOutResult()
+}
+
+// resultValueTypes returns the types of the values returned to the guest for the result.
+func resultValueTypes(v Value) []ValueType {
+	if _, ok := v.(outResult); ok {
+		return nil
+	}
+	return v.ValueTypes()
+}
+
+// Recover wraps the host function so that panics in it are recovered.
+//
+// A recovered panic is recorded in [Store.Error] as [HostPanicError],
+// so it is handled by the [ErrorPolicy] as any other error.
+// The state of [Store.Stack] after a panic is undefined.
+func (f HostFunc) Recover() HostFunc {
+	call := f.Call
+	f.Call = func(s *Store) {
+		defer func() {
+			r := recover()
+			if r != nil {
+				s.addError(&HostPanicError{
+					Module:   s.ModuleName,
+					Function: s.FuncName,
+					Value:    r,
+					Stack:    debug.Stack(),
+				})
+			}
+		}()
+		call(s)
+	}
+	return f
+}
+
+const (
+	// maxFlatParams is how many flattened params can be passed through the stack.
+	maxFlatParams = 16
+
+	// maxFlatResults is how many flattened results can be passed through the stack.
+	maxFlatResults = 1
+)
+
+// spilled describes the params and results of a host function call passed through memory.
+type spilled struct {
+	params  Addr
+	results Addr
+
+	// offsets of each param relative to the params address.
+	offsets []uint32
+
+	// inMemory tells for each param if it is read from memory.
+	// Params without flat values, like [*Store], are lifted as usual.
+	inMemory []bool
+
+	spillParams  bool
+	spillResults bool
+}
+
+// spill makes the host function pass params and results through memory
+// if they don't fit into the stack.
+//
+// The memoryLifts flags tell for each param if it implements [MemoryLift].
+// If any of the params can't be read from memory, all params are passed through the stack.
+// Params without flat values, like [*Store] or [Context], aren't passed by the guest
+// and so don't take space in memory. Results that can't be written into memory,
+// like [Pair], are returned as multiple values.
+func spill(f HostFunc, memoryLifts ...bool) HostFunc {
+	f = spillParams(f, memoryLifts)
+	if canSpillResults(f.Results) {
+		f = f.SpillResults()
+	}
+	return f
+}
+
+// spillParams makes the host function pass params through memory if they don't fit into the stack.
+func spillParams(f HostFunc, memoryLifts []bool) HostFunc {
+	if countStackValues(f.Params) <= maxFlatParams || !canSpillParams(f.Params, memoryLifts) {
+		return f
+	}
+	layouts := make([]MemoryLayout, len(f.Params))
+	inMemory := make([]bool, len(f.Params))
+	for i, p := range f.Params {
+		layouts[i] = emptyLayout{}
+		if len(p.ValueTypes()) != 0 {
+			layouts[i] = p.(MemoryLayout)
+			inMemory[i] = true
+		}
+	}
+	offsets := make([]uint32, len(layouts))
+	fieldsLayout(layouts, offsets)
+	call := f.Call
+	f.spillParams = true
+	f.Call = func(s *Store) {
+		prev := s.spilled
+		area := &spilled{
+			params:      Addr(s.Stack.Pop()),
+			offsets:     offsets,
+			inMemory:    inMemory,
+			spillParams: true,
+		}
+		// the results area is set by SpillResults which wraps this function
+		if prev != nil {
+			area.results = prev.results
+			area.spillResults = prev.spillResults
+		}
+		s.spilled = area
+		call(s)
+		s.spilled = prev
+	}
+	return f
+}
+
+// SpillResults makes the host function write the results into memory
+// if they don't fit into 1 value, the same as the component model canonical ABI does.
+//
+// The guest then passes the pointer to the return area as an extra i32 param
+// at the end, and the function returns nothing.
+//
+// Host functions constructed with [H0] to [H18] and [H0E] to [H18E] already do that
+// if all the results can be written into memory. So it's needed only
+// to undo [HostFunc.FlatResults].
+//
+// It panics if any of the results cannot be written into memory.
+func (f HostFunc) SpillResults() HostFunc {
+	if f.spillResults || len(f.ResultValueTypes()) <= maxFlatResults {
+		return f
+	}
+	if !canSpillResults(f.Results) {
+		panic("wypes: host function results cannot be written into memory")
+	}
+	call := f.Call
+	f.spillResults = true
+	f.Call = func(s *Store) {
+		if s.flatResults {
+			call(s)
+			return
+		}
+		prev := s.spilled
+		// the return area pointer is the last param, so it is popped first
+		s.spilled = &spilled{
+			results:      Addr(s.Stack.Pop()),
+			spillResults: true,
+		}
+		call(s)
+		s.spilled = prev
+	}
+	return f
+}
+
+// FlatResults makes the host function return the results as multiple values
+// even if they don't fit into 1 value.
+//
+// Use it for guests that import the function with multiple results
+// instead of passing the return area pointer. See [HostFunc.SpillResults].
+func (f HostFunc) FlatResults() HostFunc {
+	if !f.spillResults {
+		return f
+	}
+	call := f.Call
+	f.spillResults = false
+	f.Call = func(s *Store) {
+		prev := s.flatResults
+		s.flatResults = true
+		call(s)
+		s.flatResults = prev
+	}
+	return f
+}
+
+// canMemoryLift returns true if the param can be read from memory.
+func canMemoryLift[T Lift[T]](v T) bool {
+	_, ok := any(v).(MemoryLift[T])
+	return ok
+}
+
+// emptyLayout is the [MemoryLayout] of a param that takes no space in memory.
+type emptyLayout struct{}
+
+func (emptyLayout) MemorySize() uint32  { return 0 }
+func (emptyLayout) MemoryAlign() uint32 { return 1 }
+
+// canSpillParams returns true if all the params with flat values can be read from memory.
+func canSpillParams(values []Value, memoryLifts []bool) bool {
+	for i, v := range values {
+		if len(v.ValueTypes()) == 0 {
+			continue
+		}
+		if _, ok := v.(MemoryLayout); !ok || !memoryLifts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// canSpillResults returns true if all the results can be written into memory.
+func canSpillResults(values []Value) bool {
+	for _, v := range values {
+		_, ok := v.(interface {
+			MemoryLayout
+			MemoryLower[any]
+		})
+		if !ok {
+			return false
+		}
+	}
+	return true
 }
 
 func countStackValues(values []Value) int {
@@ -46,18 +292,96 @@ func mergeValueTypes(values []Value) []ValueType {
 	return res
 }
 
+// liftParam lifts the host function parameter with the given index.
+func liftParam[T Lift[T]](s *Store, v T, idx int) T {
+	s.index = idx
+	failed := s.Error != nil
+	var res T
+	if s.spilled != nil && s.spilled.spillParams && s.spilled.inMemory[idx] {
+		res = memoryLiftParam(s, v, s.spilled.params+s.spilled.offsets[idx])
+	} else {
+		res = v.Lift(s)
+	}
+	if !failed && s.Error != nil {
+		s.Error = wrapLiftError(s, v, s.Error)
+	}
+	return res
+}
+
+// memoryLiftParam lifts the host function parameter passed through memory.
+func memoryLiftParam[T Lift[T]](s *Store, v T, offset Addr) T {
+	ml, ok := any(v).(MemoryLift[T])
+	if !ok {
+		s.liftFailed(ErrSpill, v, offset, 0)
+		return v
+	}
+	res, _ := ml.MemoryLift(s, offset)
+	return res
+}
+
+// lowerResult lowers the host function result with the given index.
+func lowerResult[T Lower](s *Store, v T, idx int) {
+	s.index = idx
+	failed := s.Error != nil
+	if s.spilled != nil && s.spilled.spillResults {
+		any(v).(MemoryLower[T]).MemoryLower(s, s.spilled.results)
+	} else {
+		v.Lower(s)
+	}
+	if !failed && s.Error != nil {
+		s.Error = wrapLowerError(s, v, s.Error)
+	}
+}
+
+// wrapLiftError wraps into [LiftError] an error that was assigned
+// directly to [Store.Error] by a custom [Lift] implementation.
+func wrapLiftError(s *Store, v Value, err error) error {
+	var liftErr *LiftError
+	if errors.As(err, &liftErr) {
+		return err
+	}
+	return &LiftError{Module: s.ModuleName, Function: s.FuncName, Param: s.index, Guest: s.guest, Type: v, Err: err}
+}
+
+// wrapLowerError wraps into [LowerError] an error that was assigned
+// directly to [Store.Error] by a custom [Lower] implementation.
+func wrapLowerError(s *Store, v Value, err error) error {
+	var lowerErr *LowerError
+	if errors.As(err, &lowerErr) {
+		return err
+	}
+	return &LowerError{Module: s.ModuleName, Function: s.FuncName, Result: s.index, Guest: s.guest, Type: v, Err: err}
+}
+
+// skipResults puts zero values on the stack instead of the results
+// of a host function that was not called because lifting its parameters failed.
+func skipResults(s *Store, results ...Value) {
+	if s.spilled != nil && s.spilled.spillResults {
+		return
+	}
+	for _, v := range results {
+		for range resultValueTypes(v) {
+			s.Stack.Push(0)
+		}
+	}
+}
+
 // H0 defines a [HostFunc] that accepts no arguments.
 func H0[Z Lower](
 	fn func() Z,
 ) HostFunc {
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			fn().Lower(s)
+			if s.middleware != nil {
+				intercept(s, []Value{}, func() (Z, error) { return fn(), nil }, nil)
+				return
+			}
+			lowerResult(s, fn(), 0)
 		},
-	}
+	})
 }
 
 // H1 defines a [HostFunc] that accepts 1 high-level argument.
@@ -66,14 +390,24 @@ func H1[A Lift[A], Z Lower](
 ) HostFunc {
 	var a A
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			a := a.Lift(s)
-			fn(a).Lower(s)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a}, func() (Z, error) { return fn(a), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+	)
 }
 
 // H2 defines a [HostFunc] that accepts 2 high-level arguments.
@@ -83,15 +417,26 @@ func H2[A Lift[A], B Lift[B], Z Lower](
 	var a A
 	var b B
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b).Lower(s)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b}, func() (Z, error) { return fn(a, b), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+	)
 }
 
 // H3 defines a [HostFunc] that accepts 3 high-level arguments.
@@ -102,16 +447,28 @@ func H3[A Lift[A], B Lift[B], C Lift[C], Z Lower](
 	var b B
 	var c C
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c).Lower(s)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c}, func() (Z, error) { return fn(a, b, c), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+	)
 }
 
 // H4 defines a [HostFunc] that accepts 4 high-level arguments.
@@ -123,17 +480,30 @@ func H4[A Lift[A], B Lift[B], C Lift[C], D Lift[D], Z Lower](
 	var c C
 	var d D
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d).Lower(s)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d}, func() (Z, error) { return fn(a, b, c, d), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+	)
 }
 
 // H5 defines a [HostFunc] that accepts 5 high-level arguments.
@@ -146,18 +516,32 @@ func H5[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], Z Lower](
 	var d D
 	var e E
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e).Lower(s)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e}, func() (Z, error) { return fn(a, b, c, d, e), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+	)
 }
 
 // H6 defines a [HostFunc] that accepts 6 high-level arguments.
@@ -171,19 +555,34 @@ func H6[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], Z Lowe
 	var e E
 	var f F
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f).Lower(s)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f}, func() (Z, error) { return fn(a, b, c, d, e, f), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+	)
 }
 
 // H7 defines a [HostFunc] that accepts 7 high-level arguments.
@@ -198,20 +597,36 @@ func H7[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift
 	var f F
 	var g G
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g).Lower(s)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g}, func() (Z, error) { return fn(a, b, c, d, e, f, g), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+	)
 }
 
 // H8 defines a [HostFunc] that accepts 8 high-level arguments.
@@ -227,21 +642,38 @@ func H8[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift
 	var g G
 	var h H
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h).Lower(s)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+	)
 }
 
 // H9 defines a [HostFunc] that accepts 9 high-level arguments.
@@ -258,22 +690,40 @@ func H9[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift
 	var h H
 	var i I
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i).Lower(s)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+	)
 }
 
 // H10 defines a [HostFunc] that accepts 10 high-level arguments.
@@ -291,23 +741,42 @@ func H10[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var i I
 	var j J
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j).Lower(s)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+	)
 }
 
 // H11 defines a [HostFunc] that accepts 11 high-level arguments.
@@ -326,24 +795,44 @@ func H11[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var j J
 	var k K
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k).Lower(s)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+	)
 }
 
 // H12 defines a [HostFunc] that accepts 12 high-level arguments.
@@ -363,25 +852,46 @@ func H12[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var k K
 	var l L
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l).Lower(s)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+	)
 }
 
 // H13 defines a [HostFunc] that accepts 13 high-level arguments.
@@ -402,26 +912,48 @@ func H13[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var l L
 	var m M
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			m := m.Lift(s)
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l, m).Lower(s)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l, m), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+	)
 }
 
 // H14 defines a [HostFunc] that accepts 14 high-level arguments.
@@ -443,27 +975,50 @@ func H14[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var m M
 	var n N
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			n := n.Lift(s)
-			m := m.Lift(s)
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n).Lower(s)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+	)
 }
 
 // H15 defines a [HostFunc] that accepts 15 high-level arguments.
@@ -486,28 +1041,52 @@ func H15[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var n N
 	var o O
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			o := o.Lift(s)
-			n := n.Lift(s)
-			m := m.Lift(s)
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o).Lower(s)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+	)
 }
 
 // H16 defines a [HostFunc] that accepts 16 high-level arguments.
@@ -531,29 +1110,54 @@ func H16[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var o O
 	var p P
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			p := p.Lift(s)
-			o := o.Lift(s)
-			n := n.Lift(s)
-			m := m.Lift(s)
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p).Lower(s)
+			p := liftParam(s, p, 15)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+		canMemoryLift(p),
+	)
 }
 
 // H17 defines a [HostFunc] that accepts 17 high-level arguments.
@@ -578,30 +1182,56 @@ func H17[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var p P
 	var q Q
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			q := q.Lift(s)
-			p := p.Lift(s)
-			o := o.Lift(s)
-			n := n.Lift(s)
-			m := m.Lift(s)
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q).Lower(s)
+			q := liftParam(s, q, 16)
+			p := liftParam(s, p, 15)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+		canMemoryLift(p),
+		canMemoryLift(q),
+	)
 }
 
 // H18 defines a [HostFunc] that accepts 18 high-level arguments.
@@ -627,29 +1257,56 @@ func H18[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lif
 	var q Q
 	var r R
 	var z Z
-	return HostFunc{
+	return spill(HostFunc{
 		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r},
 		Results: []Value{z},
 		Call: func(s *Store) {
-			r := r.Lift(s)
-			q := q.Lift(s)
-			p := p.Lift(s)
-			o := o.Lift(s)
-			n := n.Lift(s)
-			m := m.Lift(s)
-			l := l.Lift(s)
-			k := k.Lift(s)
-			j := j.Lift(s)
-			i := i.Lift(s)
-			h := h.Lift(s)
-			g := g.Lift(s)
-			f := f.Lift(s)
-			e := e.Lift(s)
-			d := d.Lift(s)
-			c := c.Lift(s)
-			b := b.Lift(s)
-			a := a.Lift(s)
-			fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r).Lower(s)
+			r := liftParam(s, r, 17)
+			q := liftParam(s, q, 16)
+			p := liftParam(s, p, 15)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r), nil }, nil)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			lowerResult(s, fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r), 0)
 		},
-	}
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+		canMemoryLift(p),
+		canMemoryLift(q),
+		canMemoryLift(r),
+	)
 }
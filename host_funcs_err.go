@@ -0,0 +1,1283 @@
+package wypes
+
+// ErrorMapper converts an error returned by a host function into the function result.
+//
+// It accepts the result returned by the host function alongside the error.
+// If the mapper records an error in [Store.Error], the result is not lowered
+// and the error is handled by the [ErrorPolicy].
+//
+// Used by host functions defined with [H0E] to [H18E].
+type ErrorMapper[Z Lower] func(s *Store, res Z, err error) Z
+
+// TrapError is an [ErrorMapper] that records the error as [HostError] in [Store.Error].
+//
+// By default, that means the guest gets trapped.
+// See [ErrorPolicy] to change that.
+func TrapError[Z Lower](s *Store, res Z, err error) Z {
+	s.addError(&HostError{
+		Module:   s.ModuleName,
+		Function: s.FuncName,
+		Err:      err,
+	})
+	return res
+}
+
+// Errno is an [ErrorMapper] that returns the error code to the guest.
+//
+// The code function converts the error into an errno-style error code.
+// It is called only for non-nil errors. If code is nil, -1 is returned for all errors.
+func Errno(code func(error) Int32) ErrorMapper[Int32] {
+	return func(s *Store, res Int32, err error) Int32 {
+		if code == nil {
+			return -1
+		}
+		return code(err)
+	}
+}
+
+// ResultError is an [ErrorMapper] that writes the error into [Result].
+//
+// The convert function converts the error into the error type of the Result.
+// The Offset and DataPtr of the Result returned by the host function are preserved,
+// so the host function should set them even when returning an error.
+// Usually, Offset is the return area pointer passed by the guest as the last param.
+// The host function returns nothing to the guest.
+func ResultError[Shape MemoryLiftLower[Shape], OK MemoryLiftLower[OK], Err MemoryLiftLower[Err]](
+	convert func(error) Err,
+) ErrorMapper[Result[Shape, OK, Err]] {
+	return func(s *Store, res Result[Shape, OK, Err], err error) Result[Shape, OK, Err] {
+		res.IsError = true
+		res.Error = convert(err)
+		return res
+	}
+}
+
+// H0E is like [H0] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H0E[Z Lower](
+	fn func() (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			if s.middleware != nil {
+				intercept(s, []Value{}, func() (Z, error) { return fn() }, onErr)
+				return
+			}
+			res, err := fn()
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	})
+}
+
+// H1E is like [H1] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H1E[A Lift[A], Z Lower](
+	fn func(A) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a}, func() (Z, error) { return fn(a) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+	)
+}
+
+// H2E is like [H2] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H2E[A Lift[A], B Lift[B], Z Lower](
+	fn func(A, B) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b}, func() (Z, error) { return fn(a, b) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+	)
+}
+
+// H3E is like [H3] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H3E[A Lift[A], B Lift[B], C Lift[C], Z Lower](
+	fn func(A, B, C) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c}, func() (Z, error) { return fn(a, b, c) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+	)
+}
+
+// H4E is like [H4] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H4E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], Z Lower](
+	fn func(A, B, C, D) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d}, func() (Z, error) { return fn(a, b, c, d) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+	)
+}
+
+// H5E is like [H5] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H5E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], Z Lower](
+	fn func(A, B, C, D, E) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e}, func() (Z, error) { return fn(a, b, c, d, e) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+	)
+}
+
+// H6E is like [H6] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H6E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], Z Lower](
+	fn func(A, B, C, D, E, F) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f}, func() (Z, error) { return fn(a, b, c, d, e, f) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+	)
+}
+
+// H7E is like [H7] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H7E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], Z Lower](
+	fn func(A, B, C, D, E, F, G) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g}, func() (Z, error) { return fn(a, b, c, d, e, f, g) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+	)
+}
+
+// H8E is like [H8] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H8E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], Z Lower](
+	fn func(A, B, C, D, E, F, G, H) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+	)
+}
+
+// H9E is like [H9] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H9E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+	)
+}
+
+// H10E is like [H10] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H10E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+	)
+}
+
+// H11E is like [H11] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H11E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+	)
+}
+
+// H12E is like [H12] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H12E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+	)
+}
+
+// H13E is like [H13] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H13E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], M Lift[M], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L, M) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l, m)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+	)
+}
+
+// H14E is like [H14] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H14E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], M Lift[M], N Lift[N], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L, M, N) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+	)
+}
+
+// H15E is like [H15] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H15E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], M Lift[M], N Lift[N], O Lift[O], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L, M, N, O) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+	)
+}
+
+// H16E is like [H16] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H16E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], M Lift[M], N Lift[N], O Lift[O], P Lift[P], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var p P
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			p := liftParam(s, p, 15)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+		canMemoryLift(p),
+	)
+}
+
+// H17E is like [H17] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H17E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], M Lift[M], N Lift[N], O Lift[O], P Lift[P], Q Lift[Q], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P, Q) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var p P
+	var q Q
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			q := liftParam(s, q, 16)
+			p := liftParam(s, p, 15)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+		canMemoryLift(p),
+		canMemoryLift(q),
+	)
+}
+
+// H18E is like [H18] but the function also returns an error.
+//
+// The error is converted into the result using the given [ErrorMapper].
+// If onErr is nil, [TrapError] is used.
+func H18E[A Lift[A], B Lift[B], C Lift[C], D Lift[D], E Lift[E], F Lift[F], G Lift[G], H Lift[H], I Lift[I], J Lift[J], K Lift[K], L Lift[L], M Lift[M], N Lift[N], O Lift[O], P Lift[P], Q Lift[Q], R Lift[R], Z Lower](
+	fn func(A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P, Q, R) (Z, error),
+	onErr ErrorMapper[Z],
+) HostFunc {
+	var a A
+	var b B
+	var c C
+	var d D
+	var e E
+	var f F
+	var g G
+	var h H
+	var i I
+	var j J
+	var k K
+	var l L
+	var m M
+	var n N
+	var o O
+	var p P
+	var q Q
+	var r R
+	var z Z
+	if onErr == nil {
+		onErr = TrapError[Z]
+	}
+	return spill(HostFunc{
+		Params:  []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r},
+		Results: []Value{z},
+		Call: func(s *Store) {
+			r := liftParam(s, r, 17)
+			q := liftParam(s, q, 16)
+			p := liftParam(s, p, 15)
+			o := liftParam(s, o, 14)
+			n := liftParam(s, n, 13)
+			m := liftParam(s, m, 12)
+			l := liftParam(s, l, 11)
+			k := liftParam(s, k, 10)
+			j := liftParam(s, j, 9)
+			i := liftParam(s, i, 8)
+			h := liftParam(s, h, 7)
+			g := liftParam(s, g, 6)
+			f := liftParam(s, f, 5)
+			e := liftParam(s, e, 4)
+			d := liftParam(s, d, 3)
+			c := liftParam(s, c, 2)
+			b := liftParam(s, b, 1)
+			a := liftParam(s, a, 0)
+			if s.middleware != nil {
+				intercept(s, []Value{a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r}, func() (Z, error) { return fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r) }, onErr)
+				return
+			}
+			if !s.commitLift() {
+				skipResults(s, z)
+				return
+			}
+			res, err := fn(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r)
+			if err != nil {
+				res = onErr(s, res, err)
+				if s.Error != nil {
+					skipResults(s, z)
+					return
+				}
+			}
+			lowerResult(s, res, 0)
+		},
+	},
+		canMemoryLift(a),
+		canMemoryLift(b),
+		canMemoryLift(c),
+		canMemoryLift(d),
+		canMemoryLift(e),
+		canMemoryLift(f),
+		canMemoryLift(g),
+		canMemoryLift(h),
+		canMemoryLift(i),
+		canMemoryLift(j),
+		canMemoryLift(k),
+		canMemoryLift(l),
+		canMemoryLift(m),
+		canMemoryLift(n),
+		canMemoryLift(o),
+		canMemoryLift(p),
+		canMemoryLift(q),
+		canMemoryLift(r),
+	)
+}
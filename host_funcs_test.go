@@ -1,6 +1,8 @@
 package wypes_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/orsinium-labs/tinytest/is"
@@ -40,3 +42,308 @@ func TestH2(t *testing.T) {
 	f.Call(&store)
 	is.Equal(c, stack.Pop(), 6)
 }
+
+func TestH2_LiftErrors(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:      stack,
+		Memory:     wypes.NewSliceMemory(16),
+		ModuleName: "env",
+		FuncName:   "concat",
+	}
+	stack.Push(100) // a.offset
+	stack.Push(4)   // a.len
+	stack.Push(8)   // b.offset
+	stack.Push(12)  // b.len
+	called := false
+	f := wypes.H2(func(a, b wypes.String) wypes.Int {
+		called = true
+		return 0
+	})
+	f.Call(&store)
+	is.True(c, !called)
+	is.True(c, errors.Is(store.Error, wypes.ErrMemRead))
+
+	joined, ok := store.Error.(interface{ Unwrap() []error })
+	is.True(c, ok)
+	errs := joined.Unwrap()
+	is.Equal(c, len(errs), 2)
+
+	var err *wypes.LiftError
+	is.True(c, errors.As(errs[0], &err))
+	is.Equal(c, err.Module, "env")
+	is.Equal(c, err.Function, "concat")
+	is.Equal(c, err.Param, 1)
+	is.Equal(c, err.Addr, 8)
+	is.Equal(c, err.Len, 12)
+
+	is.True(c, errors.As(errs[1], &err))
+	is.Equal(c, err.Param, 0)
+	is.Equal(c, err.Addr, 100)
+	is.Equal(c, err.Len, 4)
+}
+
+func TestH18_LiftErrorsLimit(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:  stack,
+		Memory: wypes.NewSliceMemory(256),
+		Refs:   wypes.NewMapRefs(),
+	}
+	stack.Push(0) // params are passed through memory
+	type R = wypes.HostRef[int]
+	f := wypes.H18(func(a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r R) wypes.Void {
+		return wypes.Void{}
+	})
+	f.Call(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrRefNotFound))
+	joined, ok := store.Error.(interface{ Unwrap() []error })
+	is.True(c, ok)
+	is.Equal(c, len(joined.Unwrap()), 16)
+	is.True(c, strings.HasSuffix(store.Error.Error(), "and 2 more errors"))
+}
+
+func TestH0_LowerError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	store := wypes.Store{
+		Stack:  wypes.NewSliceStack(4),
+		Memory: wypes.NewSliceMemory(16),
+	}
+	f := wypes.H0(func() wypes.String {
+		return wypes.String{Offset: 10, Raw: "hello!!"}
+	}).FlatResults()
+	f.Call(&store)
+	var err *wypes.LowerError
+	is.True(c, errors.As(store.Error, &err))
+	is.True(c, errors.Is(err, wypes.ErrMemWrite))
+	is.Equal(c, err.Result, 0)
+	is.Equal(c, err.Addr, 10)
+	is.Equal(c, err.Len, 7)
+}
+
+var errDivZero = errors.New("division by zero")
+
+func div(a, b wypes.Int32) (wypes.Int32, error) {
+	if b == 0 {
+		return 0, errDivZero
+	}
+	return a / b, nil
+}
+
+func TestH2E(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+	stack.Push(12)
+	stack.Push(4)
+	f := wypes.H2E(div, wypes.TrapError)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Pop(), 3)
+}
+
+func TestH2E_Trap(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, ModuleName: "env", FuncName: "div"}
+	stack.Push(12)
+	stack.Push(0)
+	f := wypes.H2E(div, nil)
+	f.Call(&store)
+	is.True(c, errors.Is(store.Error, errDivZero))
+	var err *wypes.HostError
+	is.True(c, errors.As(store.Error, &err))
+	is.Equal(c, err.Function, "div")
+}
+
+func TestH2E_Errno(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+	stack.Push(12)
+	stack.Push(0)
+	f := wypes.H2E(div, wypes.Errno(func(err error) wypes.Int32 {
+		return -22
+	}))
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, wypes.Int32(stack.Pop()), -22)
+}
+
+func TestH1E_Result(t *testing.T) {
+	type R = wypes.Result[wypes.UInt32, wypes.UInt32, wypes.String]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+	stack.Push(64)
+	f := wypes.H1E(
+		func(retptr wypes.UInt32) (R, error) {
+			return R{Offset: uint32(retptr), DataPtr: 128}, errDivZero
+		},
+		wypes.ResultError[wypes.UInt32, wypes.UInt32](func(err error) wypes.String {
+			return wypes.String{Raw: err.Error()}
+		}),
+	)
+	is.Equal(c, f.NumParams(), 1)
+	is.Equal(c, f.NumResults(), 0)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Len(), 0)
+
+	store.Stack.Push(64)
+	result := R{}.Lift(&store)
+	is.True(c, result.IsError)
+	is.Equal(c, result.Error.Unwrap(), "division by zero")
+}
+
+func TestHostFunc_Recover(t *testing.T) {
+	c := is.NewRelaxed(t)
+	store := wypes.Store{Stack: wypes.NewSliceStack(4), ModuleName: "env", FuncName: "boom"}
+	f := wypes.H0(func() wypes.Void {
+		panic(errDivZero)
+	})
+	f.Recover().Call(&store)
+	var err *wypes.HostPanicError
+	is.True(c, errors.As(store.Error, &err))
+	is.Equal(c, err.Function, "boom")
+	is.True(c, len(err.Stack) > 0)
+	is.True(c, errors.Is(store.Error, errDivZero))
+}
+
+func TestHostFunc_Recover_Lift(t *testing.T) {
+	c := is.NewRelaxed(t)
+	store := wypes.Store{Stack: wypes.NewSliceStack(4)}
+	f := wypes.H1(func(x wypes.Int) wypes.Int { return x })
+	// the stack is empty, so SliceStack.Pop panics
+	f.Recover().Call(&store)
+	var err *wypes.HostPanicError
+	is.True(c, errors.As(store.Error, &err))
+}
+
+func TestH17_Spill(t *testing.T) {
+	type U = wypes.UInt64
+	type T = wypes.Tuple2[wypes.UInt8, wypes.UInt64]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: memory}
+	f := wypes.H17(func(a wypes.UInt8, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q U) T {
+		return T{F0: a, F1: b + q}
+	})
+	is.SliceEqual(c, f.ParamValueTypes(), []wypes.ValueType{wypes.ValueTypeI32, wypes.ValueTypeI32})
+	is.Equal(c, len(f.ResultValueTypes()), 0)
+
+	// params are a tuple: u8 is padded to the alignment of u64
+	params := make([]byte, 8*17)
+	params[0] = 3
+	params[8] = 4
+	params[8*16] = 5
+	memory.Write(64, params)
+
+	stack.Push(64)
+	stack.Push(512)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Len(), 0)
+	res, _ := memory.Read(512, 16)
+	is.SliceEqual(c, res, []byte{3, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0, 0})
+}
+
+func TestH18_SpillWithStore(t *testing.T) {
+	type U = wypes.UInt32
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: memory}
+	var got *wypes.Store
+	f := wypes.H18(func(s *wypes.Store, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r U) U {
+		got = s
+		return b + r
+	})
+	// *Store takes no place in memory, so the params are 17 u32 values
+	is.SliceEqual(c, f.ParamValueTypes(), []wypes.ValueType{wypes.ValueTypeI32})
+	params := make([]byte, 4*17)
+	params[0] = 3
+	params[4*16] = 4
+	memory.Write(64, params)
+	stack.Push(64)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.True(c, got == &store)
+	is.Equal(c, stack.Pop(), 7)
+}
+
+func TestH2_NoSpill(t *testing.T) {
+	c := is.NewRelaxed(t)
+	// Pair cannot be passed through memory, so it is returned as multiple values.
+	f := wypes.H2(func(a, b wypes.UInt32) wypes.Pair[wypes.UInt32, wypes.UInt32] {
+		return wypes.Pair[wypes.UInt32, wypes.UInt32]{Left: a, Right: b}
+	})
+	is.Equal(c, f.NumParams(), 2)
+	is.Equal(c, f.NumResults(), 2)
+}
+
+func TestH0_FlatResults(t *testing.T) {
+	c := is.NewRelaxed(t)
+	// by default, a string is written into the return area
+	f := wypes.H0(func() wypes.String { return wypes.String{} })
+	is.SliceEqual(c, f.ParamValueTypes(), []wypes.ValueType{wypes.ValueTypeI32})
+	is.Equal(c, f.NumResults(), 0)
+
+	// with FlatResults, it is returned as 2 values
+	f = f.FlatResults()
+	is.Equal(c, f.NumParams(), 0)
+	is.Equal(c, f.NumResults(), 2)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(64)}
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Len(), 2)
+
+	f = f.SpillResults()
+	is.SliceEqual(c, f.ParamValueTypes(), []wypes.ValueType{wypes.ValueTypeI32})
+	is.Equal(c, f.NumResults(), 0)
+
+	// a single flat result is never spilled
+	g := wypes.H0(func() wypes.UInt32 { return 0 }).SpillResults()
+	is.Equal(c, g.NumParams(), 0)
+	is.Equal(c, g.NumResults(), 1)
+}
+
+func TestHostFunc_SpillResults_Panic(t *testing.T) {
+	c := is.NewRelaxed(t)
+	defer func() {
+		is.True(c, recover() != nil)
+	}()
+	wypes.H0(func() wypes.Pair[wypes.UInt32, wypes.UInt32] {
+		return wypes.Pair[wypes.UInt32, wypes.UInt32]{}
+	}).SpillResults()
+}
+
+// lowerOnly is a value that can be written into memory but not read from it:
+// the promoted MemoryLift returns UInt64 instead of lowerOnly.
+type lowerOnly struct{ wypes.UInt64 }
+
+func (lowerOnly) Lift(s *wypes.Store) lowerOnly {
+	return lowerOnly{wypes.UInt64(s.Stack.Pop())}
+}
+
+func TestH17_NoSpillParams(t *testing.T) {
+	type U = wypes.UInt64
+	c := is.NewRelaxed(t)
+	// lowerOnly cannot be read from memory, so the params stay on the stack
+	f := wypes.H17(func(a lowerOnly, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q U) U {
+		return a.UInt64 + q
+	})
+	is.Equal(c, f.NumParams(), 17)
+	stack := wypes.NewSliceStack(17)
+	for i := 0; i < 17; i++ {
+		stack.Push(uint64(i + 1))
+	}
+	store := wypes.Store{Stack: stack}
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Pop(), 18)
+}
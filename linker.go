@@ -0,0 +1,216 @@
+package wypes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Linker defines host modules in a WebAssembly runtime.
+//
+// It is the only part that differs between runtimes. Implement it to use [Modules]
+// with a runtime that isn't supported out of the box. See [WazeroLinker] for an example.
+type Linker interface {
+	// DefineModule defines a host module with the given functions.
+	DefineModule(modName string, funcs []LinkedFunc) error
+}
+
+// LinkedFunc is a host function in a runtime-independent form passed into [Linker].
+type LinkedFunc struct {
+	// Name is the name of the function in the host module.
+	Name string
+
+	// Params are the types of the function params.
+	Params []ValueType
+
+	// Results are the types of the function results.
+	Results []ValueType
+
+	// Cancelable is set if the function is defined with [WithCancellation].
+	//
+	// If the runtime can detect it, the context passed into Call
+	// should be canceled with [ErrModuleClosed] when the guest module
+	// is closed during the call.
+	Cancelable bool
+
+	// Call calls the host function.
+	//
+	// The params must be at the beginning of the stack. After the call,
+	// the results are at the beginning of the stack, so the stack must fit
+	// both params and results. The memory, the allocator, and the instance state
+	// are of the guest module instance that called the function. Any of them can be nil.
+	//
+	// If the returned error is not nil, the guest must be trapped with it.
+	Call func(ctx context.Context, mem Memory, alloc Allocator, inst *Instance, stack []uint64) error
+}
+
+// Instance is the state of a single guest module instance.
+//
+// It lets guest instances sharing the same host modules
+// to have separate references and user state.
+type Instance struct {
+	// Refs are the references of the instance.
+	//
+	// If nil, the Refs passed into [Modules.Define] are used.
+	Refs Refs
+
+	// Data is the user state of the instance available as [Store.Data].
+	Data any
+}
+
+// Define defines all the host modules using the given [Linker].
+//
+// If refs is nil, [MapRefs] is used. With [WithMissingStubs], it also defines
+// modules imported by the guest that aren't defined in Modules.
+func (ms Modules) Define(l Linker, refs Refs, opts ...LinkOption) error {
+	if refs == nil {
+		refs = NewMapRefs()
+	}
+	for _, modName := range sortedKeys(ms) {
+		err := ms[modName].Define(l, modName, refs, opts...)
+		if err != nil {
+			return err
+		}
+	}
+	o := newOptions(opts)
+	if o.stubGuest == nil {
+		return nil
+	}
+	imports, err := parseFuncImports(o.stubGuest)
+	if err != nil {
+		return err
+	}
+	defined := map[string]bool{}
+	for _, imp := range imports {
+		_, found := ms[imp.module]
+		if found || defined[imp.module] {
+			continue
+		}
+		defined[imp.module] = true
+		err := Module{}.Define(l, imp.module, refs, opts...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Define defines the host module with the given name using the [Linker].
+//
+// If refs is nil, [MapRefs] is used.
+func (m Module) Define(l Linker, modName string, refs Refs, opts ...LinkOption) error {
+	if refs == nil {
+		refs = NewMapRefs()
+	}
+	o := newOptions(opts)
+	funcs := make([]LinkedFunc, 0, len(m))
+	for _, funcName := range sortedKeys(m) {
+		funcs = append(funcs, linkFunc(modName, funcName, m[funcName], refs, o))
+	}
+	missing, err := o.missingImports(modName, m)
+	if err != nil {
+		return err
+	}
+	for _, imp := range missing {
+		funcs = append(funcs, linkStub(modName, imp))
+		if o.stubLogger != nil {
+			o.stubLogger.Info("defined stub for missing host function", "module", modName, "function", imp.name)
+		}
+	}
+	return l.DefineModule(modName, funcs)
+}
+
+// linkFunc converts the host function into [LinkedFunc].
+func linkFunc(modName, funcName string, hf HostFunc, refs Refs, o *options) LinkedFunc {
+	numParams := hf.NumParams()
+	numResults := hf.NumResults()
+	params := hf.ParamValueTypes()
+	results := hf.ResultValueTypes()
+	hf = hf.Recover()
+	if o.metrics != nil {
+		hf = hf.Measure(o.metrics)
+	}
+	call := func(ctx context.Context, mem Memory, alloc Allocator, inst *Instance, stack []uint64) error {
+		if o.cancellation {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+		}
+		fr := framePool.Get().(*frame)
+		defer fr.release()
+		// The stack fits both params and results,
+		// so it must be trimmed for results to be pushed at the beginning.
+		fr.stack = SliceStack(stack[:numParams])
+		fr.store = Store{
+			Memory:    mem,
+			Stack:     &fr.stack,
+			Refs:      refs,
+			Allocator: alloc,
+			Context:   ctx,
+
+			ModuleName: modName,
+			FuncName:   funcName,
+		}
+		store := &fr.store
+		if inst != nil {
+			if inst.Refs != nil {
+				store.Refs = inst.Refs
+			}
+			store.Data = inst.Data
+		}
+		hf.Call(store)
+		store.endCall()
+		if o.cancellation && ctx.Err() != nil {
+			clear(stack[:numResults])
+			return fmt.Errorf("%s.%s: %w: %w", modName, funcName, ErrCanceled, context.Cause(ctx))
+		}
+		if store.Error == nil {
+			return nil
+		}
+		var panicErr *HostPanicError
+		if errors.As(store.Error, &panicErr) {
+			// the stack state is undefined after a panic,
+			// so make sure the guest gets zeros as the results.
+			clear(stack[:numResults])
+		}
+		return o.onError(ctx, store.Error)
+	}
+	return LinkedFunc{
+		Name:       funcName,
+		Params:     params,
+		Results:    results,
+		Cancelable: o.cancellation,
+		Call:       call,
+	}
+}
+
+// frame is the state of a host function call.
+//
+// Frames are reused between calls, so that calls don't allocate.
+type frame struct {
+	store Store
+	stack SliceStack
+}
+
+var framePool = sync.Pool{New: func() any { return new(frame) }}
+
+// release returns the frame into the pool.
+func (fr *frame) release() {
+	fr.store = Store{}
+	fr.stack = nil
+	framePool.Put(fr)
+}
+
+// linkStub returns a function that traps the guest with [ErrNotImplemented].
+func linkStub(modName string, imp funcImport) LinkedFunc {
+	err := fmt.Errorf("%w: %s.%s", ErrNotImplemented, modName, imp.name)
+	return LinkedFunc{
+		Name:    imp.name,
+		Params:  imp.params,
+		Results: imp.results,
+		Call: func(ctx context.Context, mem Memory, alloc Allocator, inst *Instance, stack []uint64) error {
+			return err
+		},
+	}
+}
@@ -0,0 +1,376 @@
+package wypes_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+// linkerRuntime is a runtime tested by the [wypes.Linker] conformance suite.
+type linkerRuntime interface {
+	// linker returns the linker to define host modules.
+	linker() wypes.Linker
+
+	// instantiate instantiates the guest module with the defined host modules.
+	instantiate(guest []byte) (linkerInstance, error)
+}
+
+// linkerInstance is a guest module instance in a [linkerRuntime].
+type linkerInstance interface {
+	call(name string, params ...uint64) ([]uint64, error)
+	read(offset, size uint32) []byte
+	write(offset uint32, data []byte)
+}
+
+// testLinker runs the conformance suite for a [wypes.Linker] implementation.
+//
+// The newRuntime function must return a fresh runtime for each call.
+func testLinker(t *testing.T, newRuntime func(t *testing.T) linkerRuntime) {
+	// link defines the host function as env.f and instantiates a guest
+	// that exports "run" calling it.
+	link := func(t *testing.T, hf wypes.HostFunc, refs wypes.Refs, opts ...wypes.LinkOption) linkerInstance {
+		t.Helper()
+		rt := newRuntime(t)
+		err := wypes.Modules{"env": {"f": hf}}.Define(rt.linker(), refs, opts...)
+		if err != nil {
+			t.Fatalf("define host functions: %v", err)
+		}
+		malloc := wasmFunc{
+			name:    "malloc",
+			params:  []wypes.ValueType{wypes.ValueTypeI32},
+			results: []wypes.ValueType{wypes.ValueTypeI32},
+			body:    []byte{0x41, 0x80, 0x02, 0x0b}, // i32.const 256, end
+		}
+		guest := wasmGuest("env", "f", hf.ParamValueTypes(), hf.ResultValueTypes(), malloc)
+		ins, err := rt.instantiate(guest)
+		if err != nil {
+			t.Fatalf("instantiate guest: %v", err)
+		}
+		return ins
+	}
+
+	t.Run("results", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		f := wypes.H2(func(a, b wypes.Int64) wypes.Int64 { return a - b })
+		ins := link(t, f, nil)
+		res, err := ins.call("run", 10, 3)
+		is.Err(is.Not(c), err)
+		is.SliceEqual(c, res, []uint64{7})
+	})
+
+	t.Run("memory params", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		var got string
+		f := wypes.H1(func(s wypes.String) wypes.UInt32 {
+			got = s.Raw
+			return wypes.UInt32(len(s.Raw))
+		})
+		ins := link(t, f, nil)
+		ins.write(64, []byte("hello"))
+		res, err := ins.call("run", 64, 5)
+		is.Err(is.Not(c), err)
+		is.SliceEqual(c, res, []uint64{5})
+		is.Equal(c, got, "hello")
+	})
+
+	t.Run("allocator", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		f := wypes.H0(func() wypes.String {
+			return wypes.String{Raw: "hello"}
+		})
+		ins := link(t, f, nil)
+		// the pointer and the length are written into the return area
+		res, err := ins.call("run", 64)
+		is.Err(is.Not(c), err)
+		is.Equal(c, len(res), 0)
+		is.SliceEqual(c, ins.read(64, 8), []byte{0, 1, 0, 0, 5, 0, 0, 0})
+		is.Equal(c, string(ins.read(256, 5)), "hello")
+	})
+
+	t.Run("refs", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		refs := wypes.NewMapRefs()
+		handle := refs.Put("hello")
+		f := wypes.H1(func(r wypes.HostRef[string]) wypes.UInt32 {
+			return wypes.UInt32(len(r.Raw))
+		})
+		ins := link(t, f, refs)
+		res, err := ins.call("run", uint64(handle))
+		is.Err(is.Not(c), err)
+		is.SliceEqual(c, res, []uint64{5})
+	})
+
+	t.Run("trap on error", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		called := false
+		f := wypes.H1(func(s wypes.String) wypes.UInt32 {
+			called = true
+			return 0
+		})
+		ins := link(t, f, nil)
+		_, err := ins.call("run", 1<<20, 4)
+		is.Err(c, err)
+		is.True(c, strings.Contains(err.Error(), "env.f"))
+		is.True(c, !called)
+	})
+
+	t.Run("error policy", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		f := wypes.H1(func(x wypes.Int32) wypes.Int32 {
+			panic("oh no")
+		})
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		ins := link(t, f, nil, wypes.WithErrorPolicy(wypes.LogOnError(logger)))
+		res, err := ins.call("run", 13)
+		is.Err(is.Not(c), err)
+		is.SliceEqual(c, res, []uint64{0})
+	})
+
+	t.Run("missing stubs", func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		guest := wasmGuest("env", "later", nil, nil)
+		rt := newRuntime(t)
+		err := wypes.Modules{}.Define(rt.linker(), nil, wypes.WithMissingStubs(guest, nil))
+		is.Err(is.Not(c), err)
+		ins, err := rt.instantiate(guest)
+		is.Err(is.Not(c), err)
+		_, err = ins.call("run")
+		is.Err(c, err)
+		is.True(c, strings.Contains(err.Error(), "not implemented: env.later"))
+	})
+}
+
+// recordingLinker is a [wypes.Linker] that only records the defined modules.
+type recordingLinker map[string][]wypes.LinkedFunc
+
+func (l recordingLinker) DefineModule(modName string, funcs []wypes.LinkedFunc) error {
+	l[modName] = funcs
+	return nil
+}
+
+func TestModules_Define(t *testing.T) {
+	c := is.NewRelaxed(t)
+	linker := recordingLinker{}
+	modules := wypes.Modules{"env": {
+		"b": wypes.H1(func(x wypes.Int32) wypes.Int64 { return wypes.Int64(x) }),
+		"a": wypes.H0(func() wypes.Void { return wypes.Void{} }),
+	}}
+	err := modules.Define(linker, nil)
+	is.Err(is.Not(c), err)
+	funcs := linker["env"]
+	is.Equal(c, len(funcs), 2)
+	is.Equal(c, funcs[0].Name, "a")
+	is.Equal(c, funcs[1].Name, "b")
+	is.SliceEqual(c, funcs[1].Params, []wypes.ValueType{wypes.ValueTypeI32})
+	is.SliceEqual(c, funcs[1].Results, []wypes.ValueType{wypes.ValueTypeI64})
+
+	stack := []uint64{42}
+	err = funcs[1].Call(context.Background(), nil, nil, nil, stack)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, stack, []uint64{42})
+}
+
+func TestModules_Define_Error(t *testing.T) {
+	c := is.NewRelaxed(t)
+	linker := recordingLinker{}
+	modules := wypes.Modules{"env": {
+		"f": wypes.H1(func(s wypes.String) wypes.Void { return wypes.Void{} }),
+	}}
+	err := modules.Define(linker, nil)
+	is.Err(is.Not(c), err)
+	// the memory is too small, so lifting the string fails
+	mem := wypes.NewSliceMemory(2)
+	err = linker["env"][0].Call(context.Background(), mem, nil, nil, []uint64{0, 4})
+	is.True(c, errors.Is(err, wypes.ErrMemRead))
+}
+
+func TestModule_Define_NilRefs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	linker := recordingLinker{}
+	module := wypes.Module{
+		"f": wypes.H1(func(r wypes.HostRef[int]) wypes.Void { return wypes.Void{} }),
+	}
+	err := module.Define(linker, "env", nil)
+	is.Err(is.Not(c), err)
+	err = linker["env"][0].Call(context.Background(), nil, nil, nil, []uint64{1})
+	is.True(c, errors.Is(err, wypes.ErrRefNotFound))
+}
+
+func TestLinkedFunc_NoAllocs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	linker := recordingLinker{}
+	modules := wypes.Modules{"env": {
+		"add": wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b }),
+	}}
+	err := modules.Define(linker, nil)
+	is.Err(is.Not(c), err)
+	call := linker["env"][0].Call
+	ctx := context.Background()
+	stack := []uint64{3, 4}
+	allocs := testing.AllocsPerRun(100, func() {
+		stack[0], stack[1] = 3, 4
+		_ = call(ctx, nil, nil, nil, stack)
+	})
+	is.Equal(c, allocs, 0.)
+	is.Equal(c, stack[0], 7)
+}
+
+func BenchmarkLinkedFunc(b *testing.B) {
+	linker := recordingLinker{}
+	modules := wypes.Modules{"env": {
+		"add": wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b }),
+	}}
+	err := modules.Define(linker, nil)
+	if err != nil {
+		b.Fatalf("define host functions: %v", err)
+	}
+	call := linker["env"][0].Call
+	ctx := context.Background()
+	stack := []uint64{3, 4}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stack[0], stack[1] = 3, 4
+		_ = call(ctx, nil, nil, nil, stack)
+	}
+}
+
+func TestModules_Define_Cancellation(t *testing.T) {
+	c := is.NewRelaxed(t)
+	linker := recordingLinker{}
+	var hostCtx context.Context
+	modules := wypes.Modules{"env": {
+		"f": wypes.H1(func(ctx wypes.Context) wypes.Int32 {
+			hostCtx = ctx.Unwrap()
+			return 13
+		}),
+	}}
+	err := modules.Define(linker, nil, wypes.WithCancellation(), wypes.WithErrorPolicy(wypes.LogOnError(nil)))
+	is.Err(is.Not(c), err)
+	f := linker["env"][0]
+	is.True(c, f.Cancelable)
+
+	// the host function gets a separate context canceled after the call
+	stack := []uint64{0}
+	err = f.Call(context.Background(), nil, nil, nil, stack)
+	is.Err(is.Not(c), err)
+	is.Equal(c, stack[0], 13)
+	is.True(c, errors.Is(hostCtx.Err(), context.Canceled))
+
+	// if the guest context is canceled, the results are discarded
+	// and the guest is trapped despite the error policy
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = f.Call(ctx, nil, nil, nil, stack)
+	is.True(c, errors.Is(err, wypes.ErrCanceled))
+	is.True(c, errors.Is(err, context.Canceled))
+	is.Equal(c, stack[0], 0)
+}
+
+// wasmImport is a function imported by a wasm module built with buildWasm.
+type wasmImport struct {
+	module  string
+	name    string
+	params  []wypes.ValueType
+	results []wypes.ValueType
+}
+
+// wasmFunc is a function exported by a wasm module built with buildWasm.
+type wasmFunc struct {
+	name    string
+	params  []wypes.ValueType
+	results []wypes.ValueType
+	body    []byte
+}
+
+// buildWasm builds a minimal wasm binary with the given imports and exports.
+//
+// The module also defines and exports a memory of 1 page.
+func buildWasm(imports []wasmImport, funcs []wasmFunc) []byte {
+	types := []byte{}
+	imps := []byte{}
+	for i, imp := range imports {
+		types = append(types, wasmFuncType(imp.params, imp.results)...)
+		imps = append(imps, wasmName(imp.module)...)
+		imps = append(imps, wasmName(imp.name)...)
+		imps = append(imps, 0x00) // func
+		imps = append(imps, wasmLEB(uint32(i))...)
+	}
+	fns := []byte{}
+	exps := []byte{}
+	code := []byte{}
+	for i, fn := range funcs {
+		typeIdx := uint32(len(imports) + i)
+		types = append(types, wasmFuncType(fn.params, fn.results)...)
+		fns = append(fns, wasmLEB(typeIdx)...)
+		exps = append(exps, wasmName(fn.name)...)
+		exps = append(exps, 0x00) // func
+		exps = append(exps, wasmLEB(typeIdx)...)
+		body := append([]byte{0x00}, fn.body...) // no locals
+		code = append(code, wasmVec(len(body), body)...)
+	}
+	exps = append(exps, wasmName("memory")...)
+	exps = append(exps, 0x02, 0x00) // memory 0
+
+	n := len(imports) + len(funcs)
+	bin := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	bin = append(bin, wasmSection(1, wasmVec(n, types))...)
+	bin = append(bin, wasmSection(2, wasmVec(len(imports), imps))...)
+	bin = append(bin, wasmSection(3, wasmVec(len(funcs), fns))...)
+	bin = append(bin, wasmSection(5, wasmVec(1, []byte{0x00, 0x01}))...)
+	bin = append(bin, wasmSection(7, wasmVec(len(funcs)+1, exps))...)
+	bin = append(bin, wasmSection(10, wasmVec(len(funcs), code))...)
+	return bin
+}
+
+// wasmGuest builds a wasm binary that imports modName.funcName
+// and exports "run" with the same signature forwarding all params to the import.
+//
+// Extra functions, if any, are also defined and exported.
+func wasmGuest(modName, funcName string, params, results []wypes.ValueType, extra ...wasmFunc) []byte {
+	body := []byte{}
+	for i := range params {
+		body = append(body, 0x20, byte(i)) // local.get i
+	}
+	body = append(body, 0x10, 0x00, 0x0b) // call 0, end
+	imp := wasmImport{module: modName, name: funcName, params: params, results: results}
+	run := wasmFunc{name: "run", params: params, results: results, body: body}
+	return buildWasm([]wasmImport{imp}, append([]wasmFunc{run}, extra...))
+}
+
+func wasmFuncType(params, results []wypes.ValueType) []byte {
+	sig := []byte{0x60}
+	sig = append(sig, wasmVec(len(params), params)...)
+	sig = append(sig, wasmVec(len(results), results)...)
+	return sig
+}
+
+func wasmVec(n int, data []byte) []byte {
+	return append(wasmLEB(uint32(n)), data...)
+}
+
+func wasmName(name string) []byte {
+	return wasmVec(len(name), []byte(name))
+}
+
+func wasmSection(id byte, data []byte) []byte {
+	return append([]byte{id}, wasmVec(len(data), data)...)
+}
+
+func wasmLEB(v uint32) []byte {
+	var res []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(res, b)
+		}
+		res = append(res, b|0x80)
+	}
+}
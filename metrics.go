@@ -0,0 +1,311 @@
+package wypes
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of host function calls.
+//
+// Use [HostFunc.Measure] or [WithMetrics] to measure calls.
+// [MetricsCollector] is the built-in implementation.
+type Metrics interface {
+	ObserveCall(CallMetrics)
+}
+
+// CallMetrics are measurements of a single host function call.
+type CallMetrics struct {
+	// Module is the name of the host module.
+	Module string
+
+	// Function is the name of the host function.
+	Function string
+
+	// Duration is how long the call took, including lifting and lowering.
+	Duration time.Duration
+
+	// Err is the error recorded in [Store.Error], if any.
+	Err error
+
+	// BytesRead is how many bytes were read through [Store.Memory].
+	BytesRead uint64
+
+	// BytesWritten is how many bytes were written through [Store.Memory].
+	BytesWritten uint64
+
+	// RefsCreated is how many references were put into [Store.Refs].
+	RefsCreated uint64
+
+	// RefsDropped is how many references were dropped from [Store.Refs].
+	RefsDropped uint64
+}
+
+// Error kinds returned by [ErrorKind].
+const (
+	ErrorKindLift  = "lift"
+	ErrorKindLower = "lower"
+	ErrorKindHost  = "host"
+	ErrorKindPanic = "panic"
+	ErrorKindOther = "other"
+)
+
+// ErrorKind classifies the error recorded in [Store.Error].
+//
+// It is one of [ErrorKindLift], [ErrorKindLower], [ErrorKindHost],
+// [ErrorKindPanic], [ErrorKindOther], or empty if the error is nil.
+func ErrorKind(err error) string {
+	var liftErr *LiftError
+	var lowerErr *LowerError
+	var hostErr *HostError
+	var panicErr *HostPanicError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &panicErr):
+		return ErrorKindPanic
+	case errors.As(err, &liftErr):
+		return ErrorKindLift
+	case errors.As(err, &lowerErr):
+		return ErrorKindLower
+	case errors.As(err, &hostErr):
+		return ErrorKindHost
+	}
+	return ErrorKindOther
+}
+
+// Measure wraps the host function so that every call is reported to m.
+//
+// To also measure panics, call it after [HostFunc.Recover].
+func (f HostFunc) Measure(m Metrics) HostFunc {
+	call := f.Call
+	f.Call = func(s *Store) {
+		var res CallMetrics
+		memory := s.Memory
+		refs := s.Refs
+		if memory != nil {
+			s.Memory = countingMemory{Memory: memory, metrics: &res}
+		}
+		var counting *countingRefs
+		if refs != nil {
+			counting = &countingRefs{Refs: refs, metrics: &res}
+			s.Refs = counting
+		}
+		start := time.Now()
+		call(s)
+		res.Duration = time.Since(start)
+		s.Memory = memory
+		s.Refs = refs
+		if counting != nil {
+			// Lifted values, like HostRef, can keep the refs after the call.
+			counting.metrics = nil
+		}
+		res.Module = s.ModuleName
+		res.Function = s.FuncName
+		res.Err = s.Error
+		m.ObserveCall(res)
+	}
+	return f
+}
+
+// countingMemory is a [Memory] that counts read and written bytes.
+type countingMemory struct {
+	Memory
+	metrics *CallMetrics
+}
+
+func (m countingMemory) Read(offset Addr, count uint32) ([]byte, bool) {
+	data, ok := m.Memory.Read(offset, count)
+	if ok {
+		m.metrics.BytesRead += uint64(count)
+	}
+	return data, ok
+}
+
+func (m countingMemory) Write(offset Addr, v []byte) bool {
+	ok := m.Memory.Write(offset, v)
+	if ok {
+		m.metrics.BytesWritten += uint64(len(v))
+	}
+	return ok
+}
+
+func (m countingMemory) ReadUint8(offset Addr) (byte, bool) {
+	v, ok := m.Memory.ReadUint8(offset)
+	m.countRead(ok, 1)
+	return v, ok
+}
+
+func (m countingMemory) ReadUint16Le(offset Addr) (uint16, bool) {
+	v, ok := m.Memory.ReadUint16Le(offset)
+	m.countRead(ok, 2)
+	return v, ok
+}
+
+func (m countingMemory) ReadUint32Le(offset Addr) (uint32, bool) {
+	v, ok := m.Memory.ReadUint32Le(offset)
+	m.countRead(ok, 4)
+	return v, ok
+}
+
+func (m countingMemory) ReadUint64Le(offset Addr) (uint64, bool) {
+	v, ok := m.Memory.ReadUint64Le(offset)
+	m.countRead(ok, 8)
+	return v, ok
+}
+
+func (m countingMemory) WriteUint8(offset Addr, v byte) bool {
+	ok := m.Memory.WriteUint8(offset, v)
+	m.countWrite(ok, 1)
+	return ok
+}
+
+func (m countingMemory) WriteUint16Le(offset Addr, v uint16) bool {
+	ok := m.Memory.WriteUint16Le(offset, v)
+	m.countWrite(ok, 2)
+	return ok
+}
+
+func (m countingMemory) WriteUint32Le(offset Addr, v uint32) bool {
+	ok := m.Memory.WriteUint32Le(offset, v)
+	m.countWrite(ok, 4)
+	return ok
+}
+
+func (m countingMemory) WriteUint64Le(offset Addr, v uint64) bool {
+	ok := m.Memory.WriteUint64Le(offset, v)
+	m.countWrite(ok, 8)
+	return ok
+}
+
+func (m countingMemory) countRead(ok bool, size uint64) {
+	if ok {
+		m.metrics.BytesRead += size
+	}
+}
+
+func (m countingMemory) countWrite(ok bool, size uint64) {
+	if ok {
+		m.metrics.BytesWritten += size
+	}
+}
+
+// countingRefs is [Refs] that counts created and dropped references.
+//
+// When the call is finished, metrics is set to nil
+// and the references are not counted anymore.
+type countingRefs struct {
+	Refs
+	metrics *CallMetrics
+}
+
+func (r *countingRefs) Put(val any) uint32 {
+	if r.metrics != nil {
+		r.metrics.RefsCreated++
+	}
+	return r.Refs.Put(val)
+}
+
+func (r *countingRefs) Drop(idx uint32) {
+	if r.metrics != nil {
+		r.metrics.RefsDropped++
+	}
+	r.Refs.Drop(idx)
+}
+
+// LatencyBuckets are the upper bounds of the latency histogram buckets in [FuncStats].
+//
+// The last bucket in [FuncStats.Latency] counts the calls slower than all the bounds.
+var LatencyBuckets = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// FuncStats are the aggregated metrics of a host function collected by [MetricsCollector].
+type FuncStats struct {
+	// Calls is how many times the function was called.
+	Calls uint64
+
+	// Errors maps the [ErrorKind] to how many calls failed with it.
+	Errors map[string]uint64
+
+	// Duration is the total duration of all the calls.
+	Duration time.Duration
+
+	// Latency is the histogram of the call durations.
+	//
+	// Each item is the number of calls that took at most the duration
+	// at the same index in [LatencyBuckets] but more than the previous one.
+	// The last item is for the calls slower than all of the buckets.
+	Latency []uint64
+
+	// The sums of the same fields of [CallMetrics] for all the calls.
+	BytesRead    uint64
+	BytesWritten uint64
+	RefsCreated  uint64
+	RefsDropped  uint64
+}
+
+// MetricsCollector is a [Metrics] that aggregates the metrics per host function.
+//
+// Must be constructed with [NewMetricsCollector]. It is safe for concurrent use.
+type MetricsCollector struct {
+	mu    sync.Mutex
+	stats map[string]*FuncStats
+}
+
+// NewMetricsCollector creates a new empty [MetricsCollector].
+func NewMetricsCollector() *MetricsCollector {
+	return &MetricsCollector{stats: make(map[string]*FuncStats)}
+}
+
+// ObserveCall implements [Metrics] interface.
+func (c *MetricsCollector) ObserveCall(m CallMetrics) {
+	key := m.Module + "." + m.Function
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats[key]
+	if stats == nil {
+		stats = &FuncStats{
+			Errors:  make(map[string]uint64),
+			Latency: make([]uint64, len(LatencyBuckets)+1),
+		}
+		c.stats[key] = stats
+	}
+	stats.Calls++
+	if kind := ErrorKind(m.Err); kind != "" {
+		stats.Errors[kind]++
+	}
+	stats.Duration += m.Duration
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return m.Duration <= LatencyBuckets[i]
+	})
+	stats.Latency[bucket]++
+	stats.BytesRead += m.BytesRead
+	stats.BytesWritten += m.BytesWritten
+	stats.RefsCreated += m.RefsCreated
+	stats.RefsDropped += m.RefsDropped
+}
+
+// Snapshot returns a copy of the collected metrics keyed by "module.function".
+func (c *MetricsCollector) Snapshot() map[string]FuncStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make(map[string]FuncStats, len(c.stats))
+	for key, stats := range c.stats {
+		snap := *stats
+		snap.Errors = make(map[string]uint64, len(stats.Errors))
+		for kind, count := range stats.Errors {
+			snap.Errors[kind] = count
+		}
+		snap.Latency = append([]uint64(nil), stats.Latency...)
+		res[key] = snap
+	}
+	return res
+}
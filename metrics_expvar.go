@@ -0,0 +1,15 @@
+//go:build !tinygo
+// +build !tinygo
+
+package wypes
+
+import "expvar"
+
+// Expvar returns an [expvar.Var] exposing the [MetricsCollector.Snapshot].
+//
+// Publish it with [expvar.Publish] to see the metrics at /debug/vars.
+func (c *MetricsCollector) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		return c.Snapshot()
+	})
+}
@@ -0,0 +1,103 @@
+package wypes_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func TestMeasure(t *testing.T) {
+	c := is.NewRelaxed(t)
+	collector := wypes.NewMetricsCollector()
+	f := wypes.H1(func(s wypes.String) wypes.HostRef[string] {
+		return wypes.HostRef[string]{Raw: s.Raw}
+	}).Recover().Measure(collector)
+
+	mem := wypes.NewSliceMemory(32)
+	mem.Write(8, []byte("hello"))
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:      stack,
+		Memory:     mem,
+		Refs:       wypes.NewMapRefs(),
+		ModuleName: "env",
+		FuncName:   "f",
+	}
+	stack.Push(8)
+	stack.Push(5)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, store.Memory, wypes.Memory(mem))
+
+	store.Error = nil
+	stack.Pop()
+	stack.Push(1 << 20)
+	stack.Push(5)
+	f.Call(&store)
+	is.Err(c, store.Error)
+
+	stats := collector.Snapshot()["env.f"]
+	is.Equal(c, stats.Calls, 2)
+	is.Equal(c, stats.Errors[wypes.ErrorKindLift], 1)
+	is.Equal(c, stats.BytesRead, 5)
+	is.Equal(c, stats.RefsCreated, 1)
+	total := uint64(0)
+	for _, count := range stats.Latency {
+		total += count
+	}
+	is.Equal(c, total, 2)
+
+	data, err := json.Marshal(collector.Expvar().String())
+	is.Err(is.Not(c), err)
+	is.True(c, len(data) > 0)
+}
+
+func TestErrorKind(t *testing.T) {
+	c := is.NewRelaxed(t)
+	is.Equal(c, wypes.ErrorKind(nil), "")
+	is.Equal(c, wypes.ErrorKind(&wypes.LiftError{}), wypes.ErrorKindLift)
+	is.Equal(c, wypes.ErrorKind(&wypes.LowerError{}), wypes.ErrorKindLower)
+	is.Equal(c, wypes.ErrorKind(&wypes.HostError{}), wypes.ErrorKindHost)
+	is.Equal(c, wypes.ErrorKind(&wypes.HostPanicError{}), wypes.ErrorKindPanic)
+	is.Equal(c, wypes.ErrorKind(errors.New("oops")), wypes.ErrorKindOther)
+}
+
+func TestMeasure_KeptHostRef(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var metrics []wypes.CallMetrics
+	var kept wypes.HostRef[string]
+	f := wypes.H2(func(r, d wypes.HostRef[string]) wypes.Void {
+		kept = r
+		d.Drop()
+		return wypes.Void{}
+	}).Measure(metricsFunc(func(m wypes.CallMetrics) {
+		metrics = append(metrics, m)
+	}))
+
+	refs := wypes.NewMapRefs()
+	idx := refs.Put("hi")
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Refs: refs}
+	stack.Push(wypes.Raw(idx))
+	stack.Push(wypes.Raw(refs.Put("bye")))
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, len(metrics), 1)
+	is.Equal(c, metrics[0].RefsDropped, 1)
+
+	// the reference kept after the call still drops from the store refs
+	kept.Drop()
+	_, found := refs.Get(idx, "")
+	is.True(c, !found)
+	is.Equal(c, len(refs.Raw), 0)
+}
+
+// metricsFunc is a [wypes.Metrics] calling the function.
+type metricsFunc func(wypes.CallMetrics)
+
+func (f metricsFunc) ObserveCall(m wypes.CallMetrics) {
+	f(m)
+}
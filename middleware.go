@@ -0,0 +1,185 @@
+package wypes
+
+// Middleware wraps a call of a host function.
+//
+// The middleware is called after all the params are lifted, so the lifted arguments
+// are available in [HostCall.Args]. It must call next at most once to continue
+// with the next middleware or the host function itself. If next is not called,
+// the host function is skipped and the zero value is returned to the guest.
+// Use [HostCall.Fail] to trap the guest instead.
+//
+// If lifting params fails, the middleware is still called with the error in [Store.Error],
+// so that it can observe the failed call, but next does nothing.
+//
+// Middleware is called only for host functions defined with [H0] to [H18]
+// and [H0E] to [H18E].
+type Middleware func(call *HostCall, next func())
+
+// HostCall is a single call of a host function passed into [Middleware].
+type HostCall struct {
+	// Store is the state of the call.
+	Store *Store
+
+	// Module is the name of the host module.
+	Module string
+
+	// Function is the name of the host function.
+	Function string
+
+	// Args are the lifted arguments of the host function.
+	Args []Value
+
+	// Results are the results returned by the host function.
+	//
+	// They are available after next is called.
+	Results []Value
+
+	// Lowered are the raw values of the results pushed on the stack by [Lower].
+	//
+	// They are available after next is called. If the results are passed
+	// through memory (see [HostFunc.SpillResults]), Lowered is empty
+	// and ResultsAddr is the address of the return area instead.
+	Lowered []Raw
+
+	// ResultsAddr is the address of the return area, if the results are passed through memory.
+	ResultsAddr Addr
+
+	// Err is the error returned by the host function defined with [H0E] to [H18E].
+	//
+	// It is available after next is called.
+	Err error
+}
+
+// Fail records the error in [Store.Error] as [HostError].
+//
+// If the host function hasn't been called yet, it won't be called.
+// The error is handled by the [ErrorPolicy].
+func (c *HostCall) Fail(err error) {
+	c.Store.addError(&HostError{
+		Module:   c.Module,
+		Function: c.Function,
+		Err:      err,
+	})
+}
+
+// Use wraps the host function with the given middleware.
+//
+// The middleware is called in the order it is passed. Middleware added
+// by a later call to Use, on the function or on its [Module] or [Modules],
+// wraps and so is called before the middleware added earlier.
+func (f HostFunc) Use(mws ...Middleware) HostFunc {
+	if len(mws) == 0 {
+		return f
+	}
+	call := f.Call
+	f.Call = func(s *Store) {
+		prev := s.middleware
+		s.middleware = append(prev[:len(prev):len(prev)], mws...)
+		call(s)
+		s.middleware = prev
+	}
+	return f
+}
+
+// Use returns a copy of the module with all the host functions wrapped
+// with the given middleware.
+//
+// See [HostFunc.Use].
+func (m Module) Use(mws ...Middleware) Module {
+	res := make(Module, len(m))
+	for name, f := range m {
+		res[name] = f.Use(mws...)
+	}
+	return res
+}
+
+// Use returns a copy of the modules with all the host functions wrapped
+// with the given middleware.
+//
+// See [HostFunc.Use].
+func (ms Modules) Use(mws ...Middleware) Modules {
+	res := make(Modules, len(ms))
+	for name, m := range ms {
+		res[name] = m.Use(mws...)
+	}
+	return res
+}
+
+// intercept calls the host function through the middleware chain of the store
+// and lowers the result.
+//
+// If onErr is not nil, it is used to convert the error returned by the host function.
+// The middleware is called even if lifting params failed, but then the host function is not.
+func intercept[Z Lower](s *Store, args []Value, fn func() (Z, error), onErr ErrorMapper[Z]) {
+	call := &HostCall{
+		Store:    s,
+		Module:   s.ModuleName,
+		Function: s.FuncName,
+		Args:     args,
+	}
+	mws := s.middleware
+	called := false
+	lowered := false
+	var next func()
+	next = func() {
+		if len(mws) > 0 {
+			mw := mws[0]
+			mws = mws[1:]
+			mw(call, next)
+			return
+		}
+		if called || !s.commitLift() {
+			return
+		}
+		called = true
+		res, err := fn()
+		call.Results = []Value{res}
+		call.Err = err
+		if err != nil && onErr != nil {
+			res = onErr(s, res, err)
+			if s.Error != nil {
+				return
+			}
+		}
+		lowered = true
+		lowerRecorded(s, call, res)
+	}
+	next()
+	if lowered {
+		return
+	}
+	var res Z
+	if s.Error != nil {
+		skipResults(s, res)
+		return
+	}
+	// the middleware didn't call next, so the zero value is returned.
+	lowerRecorded(s, call, res)
+}
+
+// lowerRecorded lowers the host function result and records the lowered values in the call.
+func lowerRecorded[Z Lower](s *Store, call *HostCall, res Z) {
+	if s.spilled != nil && s.spilled.spillResults {
+		call.ResultsAddr = s.spilled.results
+		lowerResult(s, res, 0)
+		return
+	}
+	stack := s.Stack
+	rec := &recordingStack{Stack: stack}
+	s.Stack = rec
+	lowerResult(s, res, 0)
+	s.Stack = stack
+	call.Lowered = rec.pushed
+}
+
+// recordingStack is a [Stack] that records all pushed values.
+type recordingStack struct {
+	Stack
+	pushed []Raw
+}
+
+// Push implements [Stack] interface.
+func (r *recordingStack) Push(v Raw) {
+	r.pushed = append(r.pushed, v)
+	r.Stack.Push(v)
+}
@@ -0,0 +1,104 @@
+package wypes_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func TestMiddleware(t *testing.T) {
+	c := is.NewRelaxed(t)
+	log := []string{}
+	logger := func(name string) wypes.Middleware {
+		return func(call *wypes.HostCall, next func()) {
+			log = append(log, name+" before")
+			next()
+			log = append(log, name+" after")
+		}
+	}
+	var got *wypes.HostCall
+	inspect := func(call *wypes.HostCall, next func()) {
+		next()
+		got = call
+	}
+	f := wypes.H2(func(a, b wypes.Int32) wypes.Int32 {
+		log = append(log, "call")
+		return a - b
+	})
+	modules := wypes.Modules{
+		"env": wypes.Module{
+			"sub": f.Use(inspect, logger("func")),
+		}.Use(logger("module")),
+	}.Use(logger("modules"))
+
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, ModuleName: "env", FuncName: "sub"}
+	stack.Push(18)
+	stack.Push(12)
+	modules["env"]["sub"].Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Pop(), 6)
+	is.SliceEqual(c, log, []string{
+		"modules before", "module before", "func before",
+		"call",
+		"func after", "module after", "modules after",
+	})
+	is.Equal(c, got.Module, "env")
+	is.Equal(c, got.Function, "sub")
+	is.SliceEqual(c, got.Args, []wypes.Value{wypes.Int32(18), wypes.Int32(12)})
+	is.SliceEqual(c, got.Results, []wypes.Value{wypes.Int32(6)})
+}
+
+func TestMiddleware_Fail(t *testing.T) {
+	c := is.NewRelaxed(t)
+	errDenied := errors.New("denied")
+	called := false
+	f := wypes.H1(func(x wypes.Int32) wypes.Int32 {
+		called = true
+		return x
+	})
+	f = f.Use(func(call *wypes.HostCall, next func()) {
+		if call.Args[0].(wypes.Int32) > 10 {
+			call.Fail(errDenied)
+			return
+		}
+		next()
+	})
+
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+	stack.Push(13)
+	f.Call(&store)
+	is.True(c, errors.Is(store.Error, errDenied))
+	is.True(c, !called)
+	is.Equal(c, stack.Len(), 1)
+
+	store = wypes.Store{Stack: stack}
+	stack.Pop()
+	stack.Push(3)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.True(c, called)
+	is.Equal(c, stack.Pop(), 3)
+}
+
+func TestMiddleware_HostError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	errOops := errors.New("oops")
+	var got error
+	f := wypes.H0E(func() (wypes.Int32, error) {
+		return 0, errOops
+	}, wypes.Errno(nil))
+	f = f.Use(func(call *wypes.HostCall, next func()) {
+		next()
+		got = call.Err
+	})
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, got, errOops)
+	is.Equal(c, wypes.Int32(stack.Pop()), -1)
+}
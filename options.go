@@ -0,0 +1,102 @@
+package wypes
+
+import "log/slog"
+
+// LinkOption configures how [Modules] are defined in a runtime.
+type LinkOption func(*options)
+
+type options struct {
+	onError ErrorPolicy
+
+	// stubGuest is the guest binary for which stubs of missing imports are defined.
+	stubGuest  []byte
+	stubLogger *slog.Logger
+
+	metrics Metrics
+
+	// cancellation is set if host function calls observe cancellation.
+	cancellation bool
+}
+
+func newOptions(opts []LinkOption) *options {
+	o := &options{
+		onError: TrapOnError,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithErrorPolicy sets the [ErrorPolicy] for errors that happen in host functions.
+//
+// By default, [TrapOnError] is used.
+func WithErrorPolicy(policy ErrorPolicy) LinkOption {
+	return func(o *options) {
+		o.onError = policy
+	}
+}
+
+// WithMetrics reports every host function call to the given [Metrics].
+//
+// See [HostFunc.Measure].
+func WithMetrics(m Metrics) LinkOption {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithCancellation makes host functions observe the cancellation of the guest.
+//
+// Every call gets a separate context, available as [Context] and [Store.Context],
+// that is canceled when the context of the guest function call is canceled
+// or, if the runtime supports it, when the guest module is closed.
+// For wazero, see [WazeroLinker.Instances].
+// If the context is canceled when the host function returns,
+// the results are discarded and the guest is trapped with [ErrCanceled]
+// regardless of the [ErrorPolicy].
+func WithCancellation() LinkOption {
+	return func(o *options) {
+		o.cancellation = true
+	}
+}
+
+// WithMissingStubs defines stubs for functions imported by the given guest binary
+// that aren't defined in [Modules].
+//
+// It lets you instantiate guests that import functions which aren't implemented yet.
+// A stub traps the guest with [ErrNotImplemented] but only if it is actually called.
+// Imports with a signature different from the host function are not stubbed,
+// use [Modules.Validate] to find them.
+//
+// If logger is not nil, every created stub is logged.
+func WithMissingStubs(guest []byte, logger *slog.Logger) LinkOption {
+	return func(o *options) {
+		o.stubGuest = guest
+		o.stubLogger = logger
+	}
+}
+
+// missingImports returns the functions of the given module
+// imported by the guest passed into [WithMissingStubs] but not defined in the module.
+func (o *options) missingImports(modName string, m Module) ([]funcImport, error) {
+	if o.stubGuest == nil {
+		return nil, nil
+	}
+	imports, err := parseFuncImports(o.stubGuest)
+	if err != nil {
+		return nil, err
+	}
+	var missing []funcImport
+	seen := map[string]bool{}
+	for _, imp := range imports {
+		if imp.module != modName || seen[imp.name] {
+			continue
+		}
+		seen[imp.name] = true
+		if _, found := m[imp.name]; !found {
+			missing = append(missing, imp)
+		}
+	}
+	return missing, nil
+}
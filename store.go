@@ -2,6 +2,9 @@ package wypes
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
 )
 
 type Raw = uint64
@@ -28,9 +31,52 @@ const (
 	ValueTypeExternref ValueType = 0x6f
 )
 
+// formatValueTypes formats value types as a comma-separated list, like "i32, f64".
+func formatValueTypes(types []ValueType) string {
+	res := ""
+	for i, t := range types {
+		if i > 0 {
+			res += ", "
+		}
+		switch t {
+		case ValueTypeI32:
+			res += "i32"
+		case ValueTypeI64:
+			res += "i64"
+		case ValueTypeF32:
+			res += "f32"
+		case ValueTypeF64:
+			res += "f64"
+		case ValueTypeExternref:
+			res += "externref"
+		default:
+			res += fmt.Sprintf("%#x", t)
+		}
+	}
+	return res
+}
+
+// formatSignature formats function signature, like "(i32, i32) -> (i64)".
+func formatSignature(params, results []ValueType) string {
+	return fmt.Sprintf("(%s) -> (%s)", formatValueTypes(params), formatValueTypes(results))
+}
+
+func equalValueTypes(a, b []ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // Store provides access for host-defined functions to the runtime data.
 //
 // Store itself implements [Lift] and so can be used as a host-defined function argument.
+// Stores are reused between host function calls, so don't keep the pointer after the call returns.
 type Store struct {
 	// Stack is where [Lift] takes the values from and [Lower] puts values to.
 	Stack Stack
@@ -43,11 +89,53 @@ type Store struct {
 	// to complex objects in the host environment that cannot be lowered into wasm.
 	Refs Refs
 
+	// Allocator is used by [Lower] of memory-based types, like [String] and [List],
+	// to allocate memory in the guest module when the offset is not provided.
+	Allocator Allocator
+
 	// Context can be retrieved by the [Context] type.
 	Context context.Context
 
-	// Error holds the latest error that happened during [Lift] or [Lower].
+	// Data is the user state attached to the calling guest module instance.
+	//
+	// It is set from [Instance.Data] if the runtime resolves state per instance,
+	// like [WazeroLinker] with [WazeroInstances].
+	Data any
+
+	// ModuleName is the name of the host module that the called function belongs to.
+	ModuleName string
+
+	// FuncName is the name of the called host function.
+	FuncName string
+
+	// Error holds the errors that happened during [Lift] or [Lower].
+	//
+	// Errors are recorded as [LiftError] and [LowerError]. If there are several,
+	// they are joined the same way as [errors.Join] does, so you can use [errors.Is]
+	// and [errors.As] to check for a specific error. Only the first 16 errors are kept.
 	Error error
+
+	// index is the index of the parameter or result being lifted or lowered.
+	index int
+
+	// guest is set if the store is used to call a guest function,
+	// so the arguments are lowered and the results are lifted.
+	guest bool
+
+	// spilled is set if the params or results of the called host function
+	// are passed through memory.
+	spilled *spilled
+
+	// flatResults is set by [HostFunc.FlatResults] to return the results
+	// as multiple values even if the host function spills them.
+	flatResults bool
+
+	// scope is the lifetime of the current host function call,
+	// created when the first [Borrow] is lifted.
+	scope *callScope
+
+	// middleware is the chain of [Middleware] for the current host function call.
+	middleware []Middleware
 }
 
 // ValueTypes implements [Value] interface.
@@ -60,15 +148,173 @@ func (*Store) Lift(s *Store) *Store {
 	return s
 }
 
+// alloc allocates memory for a value of the given type using [Store.Allocator].
+func (s *Store) alloc(typ Value, size, align uint32) (Addr, bool) {
+	addr, err := s.Allocator.Alloc(s.Context, size, align)
+	if err != nil {
+		s.lowerFailed(err, typ, 0, size)
+		return 0, false
+	}
+	return addr, true
+}
+
+// liftFailed records an error that happened when lifting a value of the given type.
+//
+// Addr and size describe the offending range in [Memory], if any.
+func (s *Store) liftFailed(err error, typ Value, addr Addr, size uint32) {
+	s.addError(&LiftError{
+		Module:   s.ModuleName,
+		Function: s.FuncName,
+		Param:    s.index,
+		Guest:    s.guest,
+		Type:     typ,
+		Addr:     addr,
+		Len:      size,
+		Err:      err,
+	})
+}
+
+// lowerFailed records an error that happened when lowering a value of the given type.
+//
+// Addr and size describe the offending range in [Memory], if any.
+func (s *Store) lowerFailed(err error, typ Value, addr Addr, size uint32) {
+	s.addError(&LowerError{
+		Module:   s.ModuleName,
+		Function: s.FuncName,
+		Result:   s.index,
+		Guest:    s.guest,
+		Type:     typ,
+		Addr:     addr,
+		Len:      size,
+		Err:      err,
+	})
+}
+
+// maxErrors is how many errors are kept in [Store.Error] during a single call.
+//
+// The errors past the limit are counted but not kept, so that a guest
+// passing a lot of bad values can't make the host spend time and memory on them.
+const maxErrors = 16
+
+// addError adds the given error to the errors already recorded in [Store.Error].
+func (s *Store) addError(err error) {
+	switch prev := s.Error.(type) {
+	case nil:
+		s.Error = err
+	case *joinedErrors:
+		prev.add(err)
+	default:
+		s.Error = &joinedErrors{errs: []error{prev, err}}
+	}
+}
+
+// errorsLen returns how many errors are recorded in [Store.Error].
+func (s *Store) errorsLen() int {
+	switch err := s.Error.(type) {
+	case nil:
+		return 0
+	case *joinedErrors:
+		return len(err.errs) + err.dropped
+	default:
+		return 1
+	}
+}
+
+// joinedErrors is the same as the error returned by [errors.Join]
+// but adding an error doesn't copy the ones already recorded
+// and the messages are joined only when the error is formatted.
+type joinedErrors struct {
+	errs []error
+
+	// dropped is how many errors are not kept because of [maxErrors].
+	dropped int
+}
+
+func (e *joinedErrors) add(err error) {
+	if len(e.errs) >= maxErrors {
+		e.dropped++
+		return
+	}
+	e.errs = append(e.errs, err)
+}
+
+// Error implements the error interface.
+func (e *joinedErrors) Error() string {
+	msgs := make([]string, 0, len(e.errs)+1)
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	if e.dropped > 0 {
+		msgs = append(msgs, fmt.Sprintf("and %d more errors", e.dropped))
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the recorded errors.
+func (e *joinedErrors) Unwrap() []error {
+	return e.errs
+}
+
 // Memory provides access to the linear memory of the wasm runtime.
 //
-// The interface is compatible with wazero memory.
+// Use [WazeroMemory] to adapt wazero memory.
 type Memory interface {
 	// Read is used to [Lift] values of memory-backed types, like [Bytes] and [String].
 	Read(offset Addr, count uint32) ([]byte, bool)
 
 	// Read is used to [Lower] values of memory-backed types, like [Bytes] and [String].
 	Write(offset Addr, v []byte) bool
+
+	// ReadUint8 and the other fixed-size reads are used by [MemoryLift]
+	// of primitive types. Unlike [Memory.Read], they don't allocate.
+	ReadUint8(offset Addr) (byte, bool)
+	ReadUint16Le(offset Addr) (uint16, bool)
+	ReadUint32Le(offset Addr) (uint32, bool)
+	ReadUint64Le(offset Addr) (uint64, bool)
+
+	// WriteUint8 and the other fixed-size writes are used by [MemoryLower]
+	// of primitive types. Unlike [Memory.Write], they don't need a buffer.
+	WriteUint8(offset Addr, v byte) bool
+	WriteUint16Le(offset Addr, v uint16) bool
+	WriteUint32Le(offset Addr, v uint32) bool
+	WriteUint64Le(offset Addr, v uint64) bool
+}
+
+// readUintLe reads a little-endian unsigned integer of the given size
+// in bytes (0, 1, 2, 4, or 8) using the fixed-size reads of [Memory].
+func readUintLe(m Memory, offset Addr, size uint32) (uint64, bool) {
+	switch size {
+	case 0:
+		return 0, true
+	case 1:
+		v, ok := m.ReadUint8(offset)
+		return uint64(v), ok
+	case 2:
+		v, ok := m.ReadUint16Le(offset)
+		return uint64(v), ok
+	case 4:
+		v, ok := m.ReadUint32Le(offset)
+		return uint64(v), ok
+	default:
+		return m.ReadUint64Le(offset)
+	}
+}
+
+// writeUintLe writes a little-endian unsigned integer of the given size
+// in bytes (0, 1, 2, 4, or 8) using the fixed-size writes of [Memory].
+func writeUintLe(m Memory, offset Addr, size uint32, v uint64) bool {
+	switch size {
+	case 0:
+		return true
+	case 1:
+		return m.WriteUint8(offset, byte(v))
+	case 2:
+		return m.WriteUint16Le(offset, uint16(v))
+	case 4:
+		return m.WriteUint32Le(offset, uint32(v))
+	default:
+		return m.WriteUint64Le(offset, v)
+	}
 }
 
 // Wraps a slice of bytes to be used as [Memory].
@@ -97,6 +343,74 @@ func (m *SliceMemory) Write(offset Addr, v []byte) bool {
 	return true
 }
 
+// ReadUint8 implements the [Memory] interface.
+func (m *SliceMemory) ReadUint8(offset Addr) (byte, bool) {
+	if !m.hasSize(offset, 1) {
+		return 0, false
+	}
+	return (*m)[offset], true
+}
+
+// ReadUint16Le implements the [Memory] interface.
+func (m *SliceMemory) ReadUint16Le(offset Addr) (uint16, bool) {
+	if !m.hasSize(offset, 2) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint16((*m)[offset:]), true
+}
+
+// ReadUint32Le implements the [Memory] interface.
+func (m *SliceMemory) ReadUint32Le(offset Addr) (uint32, bool) {
+	if !m.hasSize(offset, 4) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32((*m)[offset:]), true
+}
+
+// ReadUint64Le implements the [Memory] interface.
+func (m *SliceMemory) ReadUint64Le(offset Addr) (uint64, bool) {
+	if !m.hasSize(offset, 8) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64((*m)[offset:]), true
+}
+
+// WriteUint8 implements the [Memory] interface.
+func (m *SliceMemory) WriteUint8(offset Addr, v byte) bool {
+	if !m.hasSize(offset, 1) {
+		return false
+	}
+	(*m)[offset] = v
+	return true
+}
+
+// WriteUint16Le implements the [Memory] interface.
+func (m *SliceMemory) WriteUint16Le(offset Addr, v uint16) bool {
+	if !m.hasSize(offset, 2) {
+		return false
+	}
+	binary.LittleEndian.PutUint16((*m)[offset:], v)
+	return true
+}
+
+// WriteUint32Le implements the [Memory] interface.
+func (m *SliceMemory) WriteUint32Le(offset Addr, v uint32) bool {
+	if !m.hasSize(offset, 4) {
+		return false
+	}
+	binary.LittleEndian.PutUint32((*m)[offset:], v)
+	return true
+}
+
+// WriteUint64Le implements the [Memory] interface.
+func (m *SliceMemory) WriteUint64Le(offset Addr, v uint64) bool {
+	if !m.hasSize(offset, 8) {
+		return false
+	}
+	binary.LittleEndian.PutUint64((*m)[offset:], v)
+	return true
+}
+
 // hasSize returns true if Len is sufficient for byteCount at the given offset.
 func (m *SliceMemory) hasSize(offset uint32, byteCount uint64) bool {
 	return uint64(offset)+byteCount <= uint64(len(*m)) // uint64 prevents overflow on add
@@ -106,6 +420,44 @@ func (s *SliceMemory) Len() int {
 	return len(*s)
 }
 
+// Allocator allocates memory in the linear memory of the guest module.
+//
+// It is used by memory-based types when lowering values without a known offset.
+type Allocator interface {
+	// Alloc allocates size bytes aligned to align and returns the address of the memory.
+	Alloc(ctx context.Context, size, align uint32) (Addr, error)
+}
+
+// BumpAllocator is an [Allocator] that allocates memory sequentially
+// in the given range and never frees it.
+//
+// It is useful for tests and for guests that reserve a region of memory for the host.
+type BumpAllocator struct {
+	// Next is the address of the next allocation.
+	Next Addr
+
+	// End is the first address after the end of the available memory range.
+	End Addr
+}
+
+// Alloc implements [Allocator] interface.
+func (a *BumpAllocator) Alloc(ctx context.Context, size, align uint32) (Addr, error) {
+	if align == 0 {
+		align = 1
+	}
+	addr := alignTo(a.Next, align)
+	if uint64(addr)+uint64(size) > uint64(a.End) {
+		return 0, ErrAlloc
+	}
+	a.Next = addr + size
+	return addr, nil
+}
+
+// alignTo rounds up the address to be a multiple of align.
+func alignTo(addr Addr, align uint32) Addr {
+	return (addr + align - 1) / align * align
+}
+
 // Refs holds references to Go values that you want to reference from wasm using [HostRef].
 type Refs interface {
 	Get(idx uint32, def any) (any, bool)
@@ -217,10 +569,33 @@ type MemoryLower[T any] interface {
 	MemoryLower(*Store, Addr) uint32
 }
 
-// MemoryLiftLower is a type that implements both [MemoryLift] and [MemoryLower].
+// MemoryLayout describes the size and the alignment of a value in [Store.Memory].
+//
+// The layout follows the component model canonical ABI, so that values
+// can be nested inside of lists, results, variants, and records.
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#alignment
+type MemoryLayout interface {
+	// MemorySize returns the number of bytes the value occupies in memory,
+	// including the trailing padding. It is always a multiple of the alignment.
+	MemorySize() uint32
+
+	// MemoryAlign returns the alignment of the value in memory.
+	MemoryAlign() uint32
+}
+
+// MemoryLiftLower is a type that implements both [MemoryLift] and [MemoryLower]
+// and has a known [MemoryLayout].
 type MemoryLiftLower[T any] interface {
 	MemoryLift[T]
 	MemoryLower[T]
+	MemoryLayout
+}
+
+// FullLiftLower is a type that can be lifted and lowered
+// both through the stack and through the memory.
+type FullLiftLower[T any] interface {
+	LiftLower[T]
+	MemoryLiftLower[T]
 }
 
 // Modules is a collection of host-defined modules.
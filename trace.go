@@ -0,0 +1,153 @@
+package wypes
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// TraceFormatter is implemented by values that have a custom representation
+// in the traces written by [Trace] and [TraceLog].
+//
+// Values that don't implement it are formatted using [fmt.Stringer], if available,
+// or as "%v" otherwise.
+type TraceFormatter interface {
+	FormatTrace() string
+}
+
+// Trace returns a [Middleware] that writes every host function call into w,
+// one call per line, like:
+//
+//	env.print("Hello, Joe"@0x10) -> ()
+//
+// The arguments are the values produced by [Lift] of each param. Memory-based
+// values, like [String] and [List], are followed by their address in the memory.
+// The results are the values returned by the host function followed by the raw values
+// produced by [Lower], so that the addresses allocated for the results are shown:
+//
+//	env.greet() -> ("Hello, Joe") as (0x100, 0xa)
+//
+// If the results are passed through memory, the address of the return area
+// is shown instead, like "as @0x200". Calls that failed, including the ones
+// that failed to lift the params, are traced with the error.
+//
+// Values without value types, like [Context] and [Void], are not shown.
+func Trace(w io.Writer) Middleware {
+	var mu sync.Mutex
+	return func(call *HostCall, next func()) {
+		next()
+		line := formatTraceCall(call) + "\n"
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(w, line)
+	}
+}
+
+// TraceLog is like [Trace] but logs every host function call on the debug level.
+//
+// The log record has the module, function, args, and results attributes,
+// the lowered attribute if the results were lowered,
+// and the error attribute if the call failed.
+// If logger is nil, [slog.Default] is used.
+func TraceLog(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(call *HostCall, next func()) {
+		next()
+		attrs := []any{
+			"module", call.Module,
+			"function", call.Function,
+			"args", formatTraceValues(call.Args),
+			"results", formatTraceValues(call.Results),
+		}
+		if lowered := formatTraceLowered(call); lowered != "" {
+			attrs = append(attrs, "lowered", lowered)
+		}
+		if err := traceError(call); err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		logger.DebugContext(call.Store.Context, "host function call", attrs...)
+	}
+}
+
+// formatTraceCall formats the call, like `env.print("Hello, Joe") -> ()`.
+func formatTraceCall(call *HostCall) string {
+	msg := fmt.Sprintf(
+		"%s.%s(%s) -> (%s)",
+		call.Module, call.Function,
+		formatTraceValues(call.Args),
+		formatTraceValues(call.Results),
+	)
+	if lowered := formatTraceLowered(call); lowered != "" {
+		msg += " as " + lowered
+	}
+	if err := traceError(call); err != nil {
+		msg += fmt.Sprintf(" error: %v", err)
+	}
+	return msg
+}
+
+// formatTraceLowered formats the raw values of the lowered results, like "(0x100, 0x5)",
+// or the address of the return area, like "@0x200".
+func formatTraceLowered(call *HostCall) string {
+	if call.ResultsAddr != 0 {
+		return fmt.Sprintf("@%#x", call.ResultsAddr)
+	}
+	if len(call.Lowered) == 0 {
+		return ""
+	}
+	parts := make([]string, len(call.Lowered))
+	for i, v := range call.Lowered {
+		parts[i] = fmt.Sprintf("%#x", v)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// traceError returns the error of the call, if any.
+func traceError(call *HostCall) error {
+	if call.Err != nil {
+		return call.Err
+	}
+	return call.Store.Error
+}
+
+// formatTraceValues formats the values as a comma-separated list.
+func formatTraceValues(values []Value) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if len(v.ValueTypes()) == 0 {
+			continue
+		}
+		parts = append(parts, formatTrace(v))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatTraceSlice[T any](values []T) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatTrace(v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func formatTrace(v any) string {
+	switch v := v.(type) {
+	case TraceFormatter:
+		return v.FormatTrace()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// traceAddr formats the memory address of a traced value, if known.
+func traceAddr(offset uint32) string {
+	if offset == 0 {
+		return ""
+	}
+	return fmt.Sprintf("@%#x", offset)
+}
@@ -0,0 +1,119 @@
+package wypes_test
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func TestTrace(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out strings.Builder
+	f := wypes.H2(func(s wypes.String, n wypes.UInt32) wypes.Void {
+		return wypes.Void{}
+	}).Use(wypes.Trace(&out))
+
+	mem := wypes.NewSliceMemory(32)
+	mem.Write(16, []byte("Hello, Joe"))
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: mem, ModuleName: "env", FuncName: "print"}
+	stack.Push(16)
+	stack.Push(10)
+	stack.Push(3)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, out.String(), "env.print(\"Hello, Joe\"@0x10, 3) -> ()\n")
+}
+
+func TestTrace_Error(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out strings.Builder
+	f := wypes.H1E(func(l wypes.List[wypes.UInt16]) (wypes.Int32, error) {
+		return 0, errors.New("oops")
+	}, nil).Use(wypes.Trace(&out))
+
+	mem := wypes.NewSliceMemory(32)
+	mem.Write(8, []byte{1, 0, 2, 0})
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: mem, ModuleName: "env", FuncName: "f"}
+	stack.Push(8)
+	stack.Push(2)
+	f.Call(&store)
+	is.Err(c, store.Error)
+	is.Equal(c, out.String(), "env.f([1, 2]@0x8) -> (0) error: oops\n")
+}
+
+func TestTrace_Lowered(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out strings.Builder
+	f := wypes.H0(func() wypes.String {
+		return wypes.String{Raw: "Hello, Joe"}
+	}).FlatResults().Use(wypes.Trace(&out))
+
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:      stack,
+		Memory:     wypes.NewSliceMemory(512),
+		Allocator:  &wypes.BumpAllocator{Next: 0x100, End: 512},
+		ModuleName: "env",
+		FuncName:   "greet",
+	}
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, out.String(), "env.greet() -> (\"Hello, Joe\") as (0x100, 0xa)\n")
+}
+
+func TestTrace_SpillResults(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out strings.Builder
+	type T = wypes.Tuple2[wypes.UInt32, wypes.UInt32]
+	f := wypes.H0(func() T {
+		return T{F0: 1, F1: 2}
+	}).Use(wypes.Trace(&out))
+
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024), ModuleName: "env", FuncName: "f"}
+	stack.Push(0x200)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.True(c, strings.HasSuffix(out.String(), " as @0x200\n"))
+}
+
+func TestTrace_LiftError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out strings.Builder
+	called := false
+	f := wypes.H1(func(s wypes.String) wypes.Void {
+		called = true
+		return wypes.Void{}
+	}).Use(wypes.Trace(&out))
+
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(32), ModuleName: "env", FuncName: "print"}
+	stack.Push(64)
+	stack.Push(5)
+	f.Call(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrMemRead))
+	is.True(c, !called)
+	is.True(c, strings.HasPrefix(out.String(), "env.print(\"\"@0x40) -> () error: lift param #0"))
+}
+
+func TestTraceLog(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var out strings.Builder
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	f := wypes.H1(func(x wypes.Int32) wypes.Option[wypes.Int32] {
+		return wypes.Some(x)
+	}).FlatResults().Use(wypes.TraceLog(logger))
+
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(32), ModuleName: "env", FuncName: "f"}
+	stack.Push(7)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.True(c, strings.Contains(out.String(), `msg="host function call" module=env function=f args=7 results=some(7)`))
+}
@@ -0,0 +1,175 @@
+package wypes
+
+import "strings"
+
+// FlagSet is implemented by types that describe the flags of [Flags].
+type FlagSet interface {
+	// Flags returns the names of all the flags in order.
+	//
+	// The index of a flag name is the index of the bit representing the flag.
+	// At most 64 flags are supported, [Flags] with more flags panics.
+	Flags() []string
+}
+
+// Flags wraps a component model flags value, a set of named booleans packed as bits.
+//
+// The type parameter describes the flag names. For example:
+//
+//	type Permissions struct{}
+//
+//	func (Permissions) Flags() []string {
+//		return []string{"read", "write", "exec"}
+//	}
+//
+// Then Flags[Permissions] can be used as a host function argument or result.
+type Flags[F FlagSet] struct {
+	Raw uint64
+}
+
+// Unwrap returns the wrapped value.
+func (v Flags[F]) Unwrap() uint64 {
+	return v.Raw
+}
+
+// Has returns true if the flag with the given index is set.
+func (v Flags[F]) Has(flag int) bool {
+	return v.Raw&(1<<flag) != 0
+}
+
+// HasName returns true if the flag with the given name is set.
+func (v Flags[F]) HasName(name string) bool {
+	var f F
+	for i, n := range f.Flags() {
+		if n == name {
+			return v.Has(i)
+		}
+	}
+	return false
+}
+
+// With returns a copy of the flags with the given flags set.
+func (v Flags[F]) With(flags ...int) Flags[F] {
+	for _, flag := range flags {
+		v.Raw |= 1 << flag
+	}
+	return v
+}
+
+// Without returns a copy of the flags with the given flags unset.
+func (v Flags[F]) Without(flags ...int) Flags[F] {
+	for _, flag := range flags {
+		v.Raw &^= 1 << flag
+	}
+	return v
+}
+
+// Names returns the names of all the flags that are set.
+func (v Flags[F]) Names() []string {
+	var f F
+	names := []string{}
+	for i, n := range f.Flags() {
+		if v.Has(i) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// String returns the names of all the flags that are set separated by "|".
+func (v Flags[F]) String() string {
+	return strings.Join(v.Names(), "|")
+}
+
+// ValueTypes implements [Value] interface.
+//
+// Flags are flattened into one i32 for every 32 flags.
+func (v Flags[F]) ValueTypes() []ValueType {
+	n := (v.count() + 31) / 32
+	types := make([]ValueType, n)
+	for i := range types {
+		types[i] = ValueTypeI32
+	}
+	return types
+}
+
+// Lift implements [Lift] interface.
+func (v Flags[F]) Lift(s *Store) Flags[F] {
+	raw := popRaw(s, len(v.ValueTypes()))
+	var bits uint64
+	for i, r := range raw {
+		bits |= uint64(uint32(r)) << (32 * i)
+	}
+	return Flags[F]{Raw: bits & v.mask()}
+}
+
+// Lower implements [Lower] interface.
+func (v Flags[F]) Lower(s *Store) {
+	bits := v.Raw & v.mask()
+	for range v.ValueTypes() {
+		s.Stack.Push(Raw(uint32(bits)))
+		bits >>= 32
+	}
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Flags[F]) MemoryLift(s *Store, offset uint32) (Flags[F], uint32) {
+	size := v.MemorySize()
+	bits, ok := readUintLe(s.Memory, offset, size)
+	if !ok {
+		s.liftFailed(ErrMemRead, v, offset, size)
+		return Flags[F]{}, size
+	}
+	return Flags[F]{Raw: bits & v.mask()}, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Flags[F]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	size := v.MemorySize()
+	ok := writeUintLe(s.Memory, offset, size, v.Raw&v.mask())
+	if !ok {
+		s.lowerFailed(ErrMemWrite, v, offset, size)
+	}
+	return size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+//
+// Up to 8 flags are stored as a byte, up to 16 as uint16, and more as uint32 values.
+func (v Flags[F]) MemorySize() uint32 {
+	n := v.count()
+	switch {
+	case n <= 8:
+		return uint32(n+7) / 8
+	case n <= 16:
+		return 2
+	default:
+		return 4 * uint32((n+31)/32)
+	}
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Flags[F]) MemoryAlign() uint32 {
+	return max(min(v.MemorySize(), 4), 1)
+}
+
+// count returns the number of flags in the set.
+//
+// It panics if there are more than 64 flags, because the layout
+// would not match the one expected by the guest.
+func (Flags[F]) count() int {
+	var f F
+	n := len(f.Flags())
+	if n > 64 {
+		panic("wypes: FlagSet has more than 64 flags")
+	}
+	return n
+}
+
+// mask returns the bit mask with all the flags from the set enabled.
+func (v Flags[F]) mask() uint64 {
+	n := v.count()
+	if n == 64 {
+		return ^uint64(0)
+	}
+	return 1<<n - 1
+}
@@ -0,0 +1,121 @@
+package wypes_test
+
+import (
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+type perms struct{}
+
+func (perms) Flags() []string {
+	return []string{"read", "write", "exec"}
+}
+
+type manyFlags struct{}
+
+func (manyFlags) Flags() []string {
+	names := make([]string, 40)
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	return names
+}
+
+type tooManyFlags struct{}
+
+func (tooManyFlags) Flags() []string {
+	return make([]string, 65)
+}
+
+func TestFlags(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.Flags[perms]{}.With(0, 2)
+	is.True(c, f.Has(0))
+	is.True(c, !f.Has(1))
+	is.True(c, f.HasName("exec"))
+	is.Equal(c, f.String(), "read|exec")
+	is.Equal(c, f.Without(0).String(), "exec")
+}
+
+func TestFlags_TooMany(t *testing.T) {
+	c := is.NewRelaxed(t)
+	defer func() {
+		is.True(c, recover() != nil)
+	}()
+	wypes.Flags[tooManyFlags]{}.ValueTypes()
+}
+
+func TestFlagsStack(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+
+	f := wypes.Flags[perms]{}.With(1, 2)
+	is.Equal(c, len(f.ValueTypes()), 1)
+	f.Lower(&store)
+	is.SliceEqual(c, *stack, []uint64{0b110})
+	stack.Pop()
+
+	// unknown bits are ignored
+	stack.Push(0b11110)
+	f = wypes.Flags[perms]{}.Lift(&store)
+	is.Equal(c, f.Unwrap(), 0b110)
+
+	m := wypes.Flags[manyFlags]{}.With(0, 39)
+	is.Equal(c, len(m.ValueTypes()), 2)
+	m.Lower(&store)
+	is.SliceEqual(c, *stack, []uint64{1, 1 << 7})
+	m2 := wypes.Flags[manyFlags]{}.Lift(&store)
+	is.Equal(c, m2, m)
+}
+
+func TestFlagsMemory(t *testing.T) {
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(64)
+	store := wypes.Store{Memory: memory}
+
+	size := wypes.Flags[perms]{}.With(0, 1).MemoryLower(&store, 8)
+	is.Equal(c, size, 1)
+	f, _ := wypes.Flags[perms]{}.MemoryLift(&store, 8)
+	is.Equal(c, f.String(), "read|write")
+
+	size = wypes.Flags[manyFlags]{}.With(0, 39).MemoryLower(&store, 16)
+	is.Equal(c, size, 8)
+	raw, _ := memory.Read(16, 8)
+	is.SliceEqual(c, raw, []byte{1, 0, 0, 0, 0x80, 0, 0, 0})
+}
+
+func TestFlagsList(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+
+	data := []wypes.Flags[perms]{
+		wypes.Flags[perms]{}.With(0),
+		wypes.Flags[perms]{}.With(1, 2),
+		{},
+	}
+	wypes.List[wypes.Flags[perms]]{Offset: 64, Raw: data}.Lower(&store)
+	list := wypes.List[wypes.Flags[perms]]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
+
+func TestResultOKFlags(t *testing.T) {
+	type R = wypes.Result[wypes.Flags[perms], wypes.Flags[perms], wypes.UInt8]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+	save := R{
+		OK:      wypes.Flags[perms]{}.With(2),
+		Offset:  64,
+		DataPtr: 128,
+	}
+	save.Lower(&store)
+	store.Stack.Push(64)
+	result := R{}.Lift(&store)
+	is.Equal(c, result.IsError, false)
+	is.Equal(c, result.OK.String(), "exec")
+}
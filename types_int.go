@@ -1,7 +1,5 @@
 package wypes
 
-import "encoding/binary"
-
 // Int8 wraps [int8], a signed 8-bit integer.
 type Int8 int8
 
@@ -28,27 +26,37 @@ func (v Int8) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (Int8) MemoryLift(s *Store, offset uint32) (Int8, uint32) {
-	raw, ok := s.Memory.Read(offset, int8Size)
+func (v Int8) MemoryLift(s *Store, offset uint32) (Int8, uint32) {
+	raw, ok := s.Memory.ReadUint8(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, int8Size)
 		return Int8(0), 0
 	}
 
-	return Int8(raw[0]), int8Size
+	return Int8(raw), int8Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Int8) MemoryLower(s *Store, offset uint32) (length uint32) {
-	ok := s.Memory.Write(offset, []byte{byte(v)})
+	ok := s.Memory.WriteUint8(offset, byte(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, int8Size)
 		return 0
 	}
 
 	return int8Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Int8) MemorySize() uint32 {
+	return int8Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Int8) MemoryAlign() uint32 {
+	return int8Size
+}
+
 // Int16 wraps [int16], a signed 16-bit integer.
 type Int16 int16
 
@@ -75,29 +83,37 @@ func (v Int16) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLifter] interface.
-func (Int16) MemoryLift(s *Store, offset uint32) (Int16, uint32) {
-	raw, ok := s.Memory.Read(offset, int16Size)
+func (v Int16) MemoryLift(s *Store, offset uint32) (Int16, uint32) {
+	raw, ok := s.Memory.ReadUint16Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, int16Size)
 		return Int16(0), 0
 	}
 
-	return Int16(binary.LittleEndian.Uint16(raw)), int16Size
+	return Int16(raw), int16Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Int16) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, int16Size)
-	binary.LittleEndian.PutUint16(data, uint16(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint16Le(offset, uint16(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, int16Size)
 		return 0
 	}
 
 	return int16Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Int16) MemorySize() uint32 {
+	return int16Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Int16) MemoryAlign() uint32 {
+	return int16Size
+}
+
 // Int32 wraps [int32], a signed 32-bit integer.
 type Int32 int32
 
@@ -124,29 +140,37 @@ func (v Int32) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLifter] interface.
-func (Int32) MemoryLift(s *Store, offset uint32) (Int32, uint32) {
-	raw, ok := s.Memory.Read(offset, int32Size)
+func (v Int32) MemoryLift(s *Store, offset uint32) (Int32, uint32) {
+	raw, ok := s.Memory.ReadUint32Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, int32Size)
 		return Int32(0), 0
 	}
 
-	return Int32(binary.LittleEndian.Uint32(raw)), int32Size
+	return Int32(raw), int32Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Int32) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, int32Size)
-	binary.LittleEndian.PutUint32(data, uint32(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint32Le(offset, uint32(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, int32Size)
 		return 0
 	}
 
 	return int32Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Int32) MemorySize() uint32 {
+	return int32Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Int32) MemoryAlign() uint32 {
+	return int32Size
+}
+
 // Int64 wraps [int64], a signed 64-bit integer.
 type Int64 int64
 
@@ -173,29 +197,37 @@ func (v Int64) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLifter] interface.
-func (Int64) MemoryLift(s *Store, offset uint32) (Int64, uint32) {
-	raw, ok := s.Memory.Read(offset, int64Size)
+func (v Int64) MemoryLift(s *Store, offset uint32) (Int64, uint32) {
+	raw, ok := s.Memory.ReadUint64Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, int64Size)
 		return Int64(0), 0
 	}
 
-	return Int64(binary.LittleEndian.Uint64(raw)), int64Size
+	return Int64(raw), int64Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Int64) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, int64Size)
-	binary.LittleEndian.PutUint64(data, uint64(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint64Le(offset, uint64(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, int64Size)
 		return 0
 	}
 
 	return int64Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Int64) MemorySize() uint32 {
+	return int64Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Int64) MemoryAlign() uint32 {
+	return int64Size
+}
+
 // Int wraps [int], a signed 32-bit integer.
 type Int int
 
@@ -220,25 +252,33 @@ func (v Int) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLifter] interface.
-func (Int) MemoryLift(s *Store, offset uint32) (Int, uint32) {
-	raw, ok := s.Memory.Read(offset, int64Size)
+func (v Int) MemoryLift(s *Store, offset uint32) (Int, uint32) {
+	raw, ok := s.Memory.ReadUint64Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, int64Size)
 		return Int(0), 0
 	}
 
-	return Int(binary.LittleEndian.Uint64(raw)), int64Size
+	return Int(raw), int64Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Int) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, int64Size)
-	binary.LittleEndian.PutUint64(data, uint64(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint64Le(offset, uint64(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, int64Size)
 		return 0
 	}
 
 	return int64Size
 }
+
+// MemorySize implements [MemoryLayout] interface.
+func (Int) MemorySize() uint32 {
+	return int64Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Int) MemoryAlign() uint32 {
+	return int64Size
+}
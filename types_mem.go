@@ -1,7 +1,8 @@
 package wypes
 
 import (
-	"encoding/binary"
+	"fmt"
+	"math"
 )
 
 // Bytes wraps a slice of bytes.
@@ -11,6 +12,7 @@ import (
 // you have to provide the Offset to be able to [Lower] the value into the memory.
 // The offset should be obtained from the guest module, either as an explicit
 // function argument or by calling its allocator.
+// If Offset is zero and [Store.Allocator] is set, the allocator is called for you.
 type Bytes struct {
 	Offset uint32
 	Raw    []byte
@@ -21,66 +23,93 @@ func (v Bytes) Unwrap() []byte {
 	return v.Raw
 }
 
+// FormatTrace implements [TraceFormatter] interface.
+func (v Bytes) FormatTrace() string {
+	return fmt.Sprintf("%q", v.Raw) + traceAddr(v.Offset)
+}
+
 // ValueTypes implements [Value] interface.
 func (v Bytes) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32, ValueTypeI32}
 }
 
 // Lift implements [Lift] interface.
-func (Bytes) Lift(s *Store) Bytes {
+func (v Bytes) Lift(s *Store) Bytes {
 	size := uint32(s.Stack.Pop())
 	offset := uint32(s.Stack.Pop())
 	raw, ok := s.Memory.Read(offset, size)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, size)
 	}
 	return Bytes{Offset: offset, Raw: raw}
 }
 
 // Lower implements [Lower] interface.
 func (v Bytes) Lower(s *Store) {
+	size := uint32(len(v.Raw))
+	if v.Offset == 0 && s.Allocator != nil && size != 0 {
+		var ok bool
+		v.Offset, ok = s.alloc(v, size, 1)
+		if !ok {
+			s.Stack.Push(0)
+			s.Stack.Push(0)
+			return
+		}
+	}
 	ok := s.Memory.Write(v.Offset, v.Raw)
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, v.Offset, size)
 	}
-	size := len(v.Raw)
 	s.Stack.Push(Raw(v.Offset))
 	s.Stack.Push(Raw(size))
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (Bytes) MemoryLift(s *Store, offset uint32) (Bytes, uint32) {
-	sp, ok := s.Memory.Read(offset, 8)
+func (v Bytes) MemoryLift(s *Store, offset uint32) (Bytes, uint32) {
+	ptr, sz, ok := liftPtrLen(s, v, offset)
 	if !ok {
-		s.Error = ErrMemRead
-		return Bytes{}, 0
+		return Bytes{}, 8
 	}
-	ptr := binary.LittleEndian.Uint32(sp[0:])
-	sz := binary.LittleEndian.Uint32(sp[4:])
 
 	raw, ok := s.Memory.Read(ptr, sz)
 	if !ok {
-		s.Error = ErrMemRead
-		return Bytes{}, 0
+		s.liftFailed(ErrMemRead, v, ptr, sz)
+		return Bytes{}, 8
 	}
-	return Bytes{Offset: offset, Raw: raw}, sz
+	return Bytes{Offset: ptr, Raw: raw}, 8
 }
 
 // MemoryLower implements [MemoryLower] interface.
+//
+// The data is written at Offset if it is set. Otherwise, if [Store.Allocator] is set,
+// the data is written into a newly allocated memory. Otherwise, it fails with [ErrNoAllocator].
 func (v Bytes) MemoryLower(s *Store, offset uint32) (length uint32) {
-	ptrdata := make([]byte, 8)
-	binary.LittleEndian.PutUint32(ptrdata[0:], offset+8)
-	binary.LittleEndian.PutUint32(ptrdata[4:], uint32(len(v.Raw)))
-
-	ok := s.Memory.Write(offset, ptrdata)
-	if !ok {
-		s.Error = ErrMemWrite
+	size := uint32(len(v.Raw))
+	ptr := v.Offset
+	if ptr == 0 && size != 0 {
+		var ok bool
+		ptr, ok = lowerAlloc(s, v, offset, size, 1)
+		if !ok {
+			return 8
+		}
 	}
-	ok = s.Memory.Write(offset+8, v.Raw)
+
+	lowerPtrLen(s, v, offset, ptr, size)
+	ok := s.Memory.Write(ptr, v.Raw)
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, ptr, size)
 	}
-	return uint32(len(v.Raw))
+	return 8
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (Bytes) MemorySize() uint32 {
+	return 8
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Bytes) MemoryAlign() uint32 {
+	return 4
 }
 
 // String wraps [string].
@@ -90,6 +119,7 @@ func (v Bytes) MemoryLower(s *Store, offset uint32) (length uint32) {
 // you have to provide the Offset to be able to [Lower] the value into the memory.
 // The offset should be obtained from the guest module, either as an explicit
 // function argument or by calling its allocator.
+// If Offset is zero and [Store.Allocator] is set, the allocator is called for you.
 type String struct {
 	Offset uint32
 	Raw    string
@@ -100,66 +130,93 @@ func (v String) Unwrap() string {
 	return v.Raw
 }
 
+// FormatTrace implements [TraceFormatter] interface.
+func (v String) FormatTrace() string {
+	return fmt.Sprintf("%q", v.Raw) + traceAddr(v.Offset)
+}
+
 // ValueTypes implements [Value] interface.
 func (v String) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32, ValueTypeI32}
 }
 
 // Lift implements [Lift] interface.
-func (String) Lift(s *Store) String {
+func (v String) Lift(s *Store) String {
 	size := uint32(s.Stack.Pop())
 	offset := uint32(s.Stack.Pop())
 	raw, ok := s.Memory.Read(offset, size)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, size)
 	}
 	return String{Offset: offset, Raw: string(raw)}
 }
 
 // Lower implements [Lower] interface.
 func (v String) Lower(s *Store) {
+	size := uint32(len(v.Raw))
+	if v.Offset == 0 && s.Allocator != nil && size != 0 {
+		var ok bool
+		v.Offset, ok = s.alloc(v, size, 1)
+		if !ok {
+			s.Stack.Push(0)
+			s.Stack.Push(0)
+			return
+		}
+	}
 	ok := s.Memory.Write(v.Offset, []byte(v.Raw))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, v.Offset, size)
 	}
-	size := len(v.Raw)
 	s.Stack.Push(Raw(v.Offset))
 	s.Stack.Push(Raw(size))
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (String) MemoryLift(s *Store, offset uint32) (String, uint32) {
-	sp, ok := s.Memory.Read(offset, 8)
+func (v String) MemoryLift(s *Store, offset uint32) (String, uint32) {
+	ptr, sz, ok := liftPtrLen(s, v, offset)
 	if !ok {
-		s.Error = ErrMemRead
-		return String{}, 0
+		return String{}, 8
 	}
-	ptr := binary.LittleEndian.Uint32(sp[0:])
-	sz := binary.LittleEndian.Uint32(sp[4:])
 
 	raw, ok := s.Memory.Read(ptr, sz)
 	if !ok {
-		s.Error = ErrMemRead
-		return String{}, 0
+		s.liftFailed(ErrMemRead, v, ptr, sz)
+		return String{}, 8
 	}
-	return String{Offset: offset, Raw: string(raw)}, sz
+	return String{Offset: ptr, Raw: string(raw)}, 8
 }
 
 // MemoryLower implements [MemoryLower] interface.
+//
+// The data is written at Offset if it is set. Otherwise, if [Store.Allocator] is set,
+// the data is written into a newly allocated memory. Otherwise, it fails with [ErrNoAllocator].
 func (v String) MemoryLower(s *Store, offset uint32) (length uint32) {
-	ptrdata := make([]byte, 8)
-	binary.LittleEndian.PutUint32(ptrdata[0:], offset+8)
-	binary.LittleEndian.PutUint32(ptrdata[4:], uint32(len(v.Raw)))
-
-	ok := s.Memory.Write(offset, ptrdata)
-	if !ok {
-		s.Error = ErrMemWrite
+	size := uint32(len(v.Raw))
+	ptr := v.Offset
+	if ptr == 0 && size != 0 {
+		var ok bool
+		ptr, ok = lowerAlloc(s, v, offset, size, 1)
+		if !ok {
+			return 8
+		}
 	}
-	ok = s.Memory.Write(offset+8, []byte(v.Raw))
+
+	lowerPtrLen(s, v, offset, ptr, size)
+	ok := s.Memory.Write(ptr, []byte(v.Raw))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, ptr, size)
 	}
-	return uint32(len(v.Raw))
+	return 8
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (String) MemorySize() uint32 {
+	return 8
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (String) MemoryAlign() uint32 {
+	return 4
 }
 
 // ReturnedList wraps a Go slice of any type that supports the [MemoryLiftLower] interface so it can be returned as a List.
@@ -176,60 +233,53 @@ func (v ReturnedList[T]) Unwrap() []T {
 	return v.Raw
 }
 
+// FormatTrace implements [TraceFormatter] interface.
+func (v ReturnedList[T]) FormatTrace() string {
+	return formatTraceSlice(v.Raw) + traceAddr(v.DataPtr)
+}
+
 // ValueTypes implements [Value] interface.
 func (v ReturnedList[T]) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32}
 }
 
 // Lift implements [Lift] interface.
-func (ReturnedList[T]) Lift(s *Store) ReturnedList[T] {
+func (v ReturnedList[T]) Lift(s *Store) ReturnedList[T] {
 	offset := uint32(s.Stack.Pop())
-	buf, ok := s.Memory.Read(offset, 8)
+	ptr, sz, ok := liftPtrLen(s, v, offset)
 	if !ok {
-		s.Error = ErrMemRead
 		return ReturnedList[T]{}
 	}
 
-	ptr := binary.LittleEndian.Uint32(buf[0:])
-	sz := binary.LittleEndian.Uint32(buf[4:])
-
 	// empty list, probably a return value to be filled in later.
 	if ptr == 0 || sz == 0 {
 		return ReturnedList[T]{Offset: offset}
 	}
 
-	data := make([]T, sz)
-	p := ptr
-	var length uint32
-	for i := uint32(0); i < sz; i++ {
-		data[i], length = T.MemoryLift(data[0], s, p)
-		p += length
-	}
-
+	data := liftElems[T](s, v, ptr, sz)
 	return ReturnedList[T]{Offset: offset, DataPtr: ptr, Raw: data}
 }
 
 // Lower implements [Lower] interface.
 // See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
-// To use this need to have pre-allocated linear memory into which to write the actual data.
+// To use this need to have pre-allocated linear memory into which to write the actual data,
+// or to have [Store.Allocator] set.
 func (v ReturnedList[T]) Lower(s *Store) {
+	size := len(v.Raw)
+	if v.DataPtr == 0 && s.Allocator != nil && size != 0 {
+		var ok bool
+		v.DataPtr, ok = allocList(s, v, v.Raw)
+		if !ok {
+			return
+		}
+	}
 	if v.DataPtr == 0 {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, v.DataPtr, 0)
 		return
 	}
 
-	size := len(v.Raw)
-
-	ptr := v.DataPtr
-	for i := uint32(0); i < uint32(size); i++ {
-		length := v.Raw[i].MemoryLower(s, ptr)
-		ptr += length
-	}
-
-	ptrdata := make([]byte, 8)
-	binary.LittleEndian.PutUint32(ptrdata[0:], v.DataPtr)
-	binary.LittleEndian.PutUint32(ptrdata[4:], uint32(len(v.Raw)))
-	s.Memory.Write(v.Offset, ptrdata)
+	lowerElems(s, v.DataPtr, v.Raw)
+	lowerPtrLen(s, v, v.Offset, v.DataPtr, uint32(len(v.Raw)))
 }
 
 // List wraps a Go slice of any type that implements the [MemoryLiftLower] interface.
@@ -244,6 +294,11 @@ func (v List[T]) Unwrap() []T {
 	return v.Raw
 }
 
+// FormatTrace implements [TraceFormatter] interface.
+func (v List[T]) FormatTrace() string {
+	return formatTraceSlice(v.Raw) + traceAddr(v.Offset)
+}
+
 // ValueTypes implements [Value] interface.
 func (v List[T]) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32, ValueTypeI32}
@@ -257,70 +312,72 @@ func (List[T]) Lift(s *Store) List[T] {
 	if size == 0 {
 		return List[T]{Offset: offset}
 	}
-	data := make([]T, size)
-	ptr := offset
-	var length uint32
-	for i := uint32(0); i < size; i++ {
-		data[i], length = T.MemoryLift(data[0], s, ptr)
-		ptr += length
-	}
+	data := liftElems[T](s, List[T]{}, offset, size)
 	return List[T]{Offset: offset, Raw: data}
 }
 
 // Lower implements [Lower] interface.
 // See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
-// In theory we should re-allocate enough linear memory into which to write the actual data.
+// If Offset is zero and [Store.Allocator] is set, the memory for the list is allocated in the guest.
 func (v List[T]) Lower(s *Store) {
 	size := len(v.Raw)
-	ptr := v.Offset
-	for i := uint32(0); i < uint32(size); i++ {
-		length := v.Raw[i].MemoryLower(s, ptr)
-		ptr += length
+	if v.Offset == 0 && s.Allocator != nil && size != 0 {
+		var ok bool
+		v.Offset, ok = allocList(s, v, v.Raw)
+		if !ok {
+			s.Stack.Push(0)
+			s.Stack.Push(0)
+			return
+		}
 	}
+	lowerElems(s, v.Offset, v.Raw)
 	s.Stack.Push(Raw(v.Offset))
 	s.Stack.Push(Raw(size))
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (List[T]) MemoryLift(s *Store, offset uint32) (List[T], uint32) {
-	sp, ok := s.Memory.Read(offset, 8)
+func (v List[T]) MemoryLift(s *Store, offset uint32) (List[T], uint32) {
+	ptr, sz, ok := liftPtrLen(s, v, offset)
 	if !ok {
-		s.Error = ErrMemRead
-		return List[T]{}, 0
+		return List[T]{}, 8
 	}
-	ptr := binary.LittleEndian.Uint32(sp[0:])
-	sz := binary.LittleEndian.Uint32(sp[4:])
-
-	data := make([]T, sz)
-	var v T
-	var length uint32
-	for i := uint32(0); i < uint32(sz); i++ {
-		data[i], length = v.MemoryLift(s, ptr)
-		ptr += length
+	if sz == 0 {
+		return List[T]{Offset: ptr}, 8
 	}
-
-	return List[T]{Offset: offset, Raw: data}, sz
+	data := liftElems[T](s, v, ptr, sz)
+	return List[T]{Offset: ptr, Raw: data}, 8
 }
 
 // MemoryLower implements [MemoryLower] interface.
+//
+// The elements are written at Offset if it is set. Otherwise, if [Store.Allocator] is set,
+// the elements are written into a newly allocated memory. Otherwise, it fails with [ErrNoAllocator].
 func (v List[T]) MemoryLower(s *Store, offset uint32) (length uint32) {
-	sz := len(v.Raw)
-	ptr := offset + 8
-	for i := uint32(0); i < uint32(sz); i++ {
-		length := v.Raw[i].MemoryLower(s, ptr)
-		ptr += length
+	ptr := v.Offset
+	if ptr == 0 && len(v.Raw) != 0 {
+		var elem T
+		size := elem.MemorySize() * uint32(len(v.Raw))
+		var ok bool
+		ptr, ok = lowerAlloc(s, v, offset, size, elem.MemoryAlign())
+		if !ok {
+			return 8
+		}
 	}
+	lowerElems(s, ptr, v.Raw)
 
-	ptrdata := make([]byte, 8)
-	binary.LittleEndian.PutUint32(ptrdata[0:], offset+8)
-	binary.LittleEndian.PutUint32(ptrdata[4:], uint32(len(v.Raw)))
+	lowerPtrLen(s, v, offset, ptr, uint32(len(v.Raw)))
 
-	ok := s.Memory.Write(offset, ptrdata)
-	if !ok {
-		s.Error = ErrMemWrite
-	}
+	return 8
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (List[T]) MemorySize() uint32 {
+	return 8
+}
 
-	return uint32(sz)
+// MemoryAlign implements [MemoryLayout] interface.
+func (List[T]) MemoryAlign() uint32 {
+	return 4
 }
 
 // ListStrings wraps a Go slice of strings.
@@ -337,13 +394,18 @@ func (v ListStrings) Unwrap() []string {
 	return v.Raw
 }
 
+// FormatTrace implements [TraceFormatter] interface.
+func (v ListStrings) FormatTrace() string {
+	return fmt.Sprintf("%q", v.Raw) + traceAddr(v.Offset)
+}
+
 // ValueTypes implements [Value] interface.
 func (v ListStrings) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32, ValueTypeI32}
 }
 
 // Lift implements [Lift] interface.
-func (ListStrings) Lift(s *Store) ListStrings {
+func (v ListStrings) Lift(s *Store) ListStrings {
 	size := uint32(s.Stack.Pop())
 	offset := uint32(s.Stack.Pop())
 
@@ -355,18 +417,14 @@ func (ListStrings) Lift(s *Store) ListStrings {
 	data := make([]string, size)
 
 	for i := uint32(0); i < size; i++ {
-		buf, ok := s.Memory.Read(offset+i*8, 8)
+		ptr, sz, ok := liftPtrLen(s, v, offset+i*8)
 		if !ok {
-			s.Error = ErrMemRead
 			return ListStrings{Offset: offset, Raw: data}
 		}
 
-		ptr := binary.LittleEndian.Uint32(buf[0:])
-		sz := binary.LittleEndian.Uint32(buf[4:])
-
 		raw, ok := s.Memory.Read(ptr, sz)
 		if !ok {
-			s.Error = ErrMemRead
+			s.liftFailed(ErrMemRead, v, ptr, sz)
 			return ListStrings{Offset: offset, Raw: data}
 		}
 
@@ -378,33 +436,38 @@ func (ListStrings) Lift(s *Store) ListStrings {
 
 // Lower implements [Lower] interface.
 // See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
-// In theory we should re-allocate enough linear memory into which to write the actual data.
+// If Offset is zero and [Store.Allocator] is set, the memory for the list is allocated in the guest.
 func (v ListStrings) Lower(s *Store) {
 	size := uint32(len(v.Raw))
 	plen := size * 8
-
-	// write pointers
-	for i := uint32(0); i < size; i++ {
-		ptrdata := make([]byte, 8)
-		binary.LittleEndian.PutUint32(ptrdata[0:], v.Offset+i*8+plen)
-		binary.LittleEndian.PutUint32(ptrdata[4:], uint32(len(v.Raw[i])))
-
-		ok := s.Memory.Write(v.Offset+i*8, ptrdata)
+	if v.Offset == 0 && s.Allocator != nil && size != 0 {
+		total := plen
+		for _, str := range v.Raw {
+			total += uint32(len(str))
+		}
+		var ok bool
+		v.Offset, ok = s.alloc(v, total, 4)
 		if !ok {
-			s.Error = ErrMemRead
+			s.Stack.Push(0)
+			s.Stack.Push(0)
 			return
 		}
 	}
 
-	// write the actual strings
+	// write pointers and the actual strings right after them
+	ptr := v.Offset + plen
 	for i, str := range v.Raw {
-		ptr := v.Offset + plen + uint32(i)*8
+		ok := lowerPtrLen(s, v, v.Offset+uint32(i)*8, ptr, uint32(len(str)))
+		if !ok {
+			return
+		}
 
-		ok := s.Memory.Write(ptr, []byte(str))
+		ok = s.Memory.Write(ptr, []byte(str))
 		if !ok {
-			s.Error = ErrMemRead
+			s.lowerFailed(ErrMemWrite, v, ptr, uint32(len(str)))
 			return
 		}
+		ptr += uint32(len(str))
 	}
 
 	s.Stack.Push(Raw(v.Offset))
@@ -413,6 +476,10 @@ func (v ListStrings) Lower(s *Store) {
 
 // Result is the implementation required for the host side of component model functions that return a *[cm.Result] type.
 // See https://github.com/bytecodealliance/wasm-tools-go/blob/main/cm/result.go
+//
+// Same as in cm, Shape is the type whose size is used for the payload,
+// usually the bigger one of OK and Err. In memory, the result is stored as the discriminant byte
+// followed by the payload aligned to the biggest alignment of Shape, OK, and Err.
 type Result[Shape MemoryLiftLower[Shape], OK MemoryLiftLower[OK], Err MemoryLiftLower[Err]] struct {
 	Offset  uint32
 	DataPtr uint32
@@ -430,57 +497,324 @@ func (v Result[Shape, OK, Err]) Unwrap() any {
 	return v.OK
 }
 
+// FormatTrace implements [TraceFormatter] interface.
+func (v Result[Shape, OK, Err]) FormatTrace() string {
+	if v.IsError {
+		return "err(" + formatTrace(v.Error) + ")" + traceAddr(v.Offset)
+	}
+	return "ok(" + formatTrace(v.OK) + ")" + traceAddr(v.Offset)
+}
+
+// isOutResult implements outResult interface.
+//
+// As a host function result, Result is written into memory at Offset,
+// so the function returns nothing to the guest.
+func (Result[Shape, OK, Err]) isOutResult() {}
+
 // ValueTypes implements [Value] interface.
 func (v Result[Shape, OK, Err]) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32}
 }
 
-// Lift implements [Lift] interface. Lifting a result is not supported.
-func (Result[Shape, OK, Err]) Lift(s *Store) Result[Shape, OK, Err] {
+// Lift implements [Lift] interface.
+//
+// The result is read from the memory at the address popped from the stack.
+func (v Result[Shape, OK, Err]) Lift(s *Store) Result[Shape, OK, Err] {
 	offset := uint32(s.Stack.Pop())
+	res, _ := v.MemoryLift(s, offset)
+	return res
+}
 
-	var B UInt32
-	isError, sz := B.MemoryLift(s, offset)
+// Lower implements [Lower] interface.
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
+//
+// The result is written into memory at Offset and nothing is pushed on the stack.
+// The data of the payload is written starting at DataPtr or, if it is zero,
+// into memory allocated by [Store.Allocator].
+func (v Result[Shape, OK, Err]) Lower(s *Store) {
+	v.MemoryLower(s, v.Offset)
+}
 
-	if isError > 0 {
-		var E Err
-		err, _ := E.MemoryLift(s, offset+sz)
-		return Result[Shape, OK, Err]{
-			IsError: true,
-			Error:   err,
-			Offset:  offset,
-		}
+// MemoryLift implements [MemoryLift] interface.
+func (v Result[Shape, OK, Err]) MemoryLift(s *Store, offset uint32) (Result[Shape, OK, Err], uint32) {
+	size := v.MemorySize()
+	isError, ok := liftDiscriminant(s, v, offset, 1)
+	if !ok {
+		return Result[Shape, OK, Err]{}, size
+	}
+	payload := offset + v.MemoryAlign()
+	switch isError {
+	case 0:
+		val, _ := v.OK.MemoryLift(s, payload)
+		return Result[Shape, OK, Err]{OK: val, Offset: offset}, size
+	case 1:
+		err, _ := v.Error.MemoryLift(s, payload)
+		return Result[Shape, OK, Err]{IsError: true, Error: err, Offset: offset}, size
+	default:
+		s.liftFailed(ErrDiscriminant, v, offset, 1)
+		return Result[Shape, OK, Err]{}, size
 	}
+}
 
-	var T OK
-	val, _ := T.MemoryLift(s, offset+sz)
-	return Result[Shape, OK, Err]{
-		IsError: false,
-		OK:      val,
-		Offset:  offset,
+// MemoryLower implements [MemoryLower] interface.
+//
+// If DataPtr is not zero, the data of the payload, like the bytes of a [String]
+// without an explicit Offset, is written into memory starting at DataPtr
+// instead of memory allocated by [Store.Allocator].
+func (v Result[Shape, OK, Err]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	size := v.MemorySize()
+	if v.DataPtr != 0 {
+		alloc := s.Allocator
+		// The memory past DataPtr is pre-allocated by the guest, so its size is unknown.
+		// Writes out of the memory bounds still fail with [ErrMemWrite].
+		s.Allocator = &BumpAllocator{Next: v.DataPtr, End: math.MaxUint32}
+		defer func() { s.Allocator = alloc }()
+	}
+	var isError uint32
+	if v.IsError {
+		isError = 1
+	}
+	if !lowerDiscriminant(s, v, offset, 1, isError) {
+		return size
+	}
+	payload := offset + v.MemoryAlign()
+	if v.IsError {
+		v.Error.MemoryLower(s, payload)
+	} else {
+		v.OK.MemoryLower(s, payload)
+	}
+	return size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+//
+// The result is stored as the discriminant byte, the padding to the alignment of the payload,
+// and the payload big enough to fit Shape, OK, and Err.
+func (v Result[Shape, OK, Err]) MemorySize() uint32 {
+	var shape Shape
+	align := v.MemoryAlign()
+	size := max(shape.MemorySize(), v.OK.MemorySize(), v.Error.MemorySize())
+	return alignTo(align+size, align)
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Result[Shape, OK, Err]) MemoryAlign() uint32 {
+	var shape Shape
+	return max(shape.MemoryAlign(), v.OK.MemoryAlign(), v.Error.MemoryAlign(), 1)
+}
+
+// Option wraps an optional value of any type that can be passed both through the stack and memory.
+// This is the implementation required for the host side of component model functions
+// that accept or return [cm.Option].
+// See https://github.com/bytecodealliance/wasm-tools-go/blob/main/cm/option.go
+type Option[T FullLiftLower[T]] struct {
+	Raw    T
+	IsSome bool
+}
+
+// Some creates an [Option] with the given value.
+func Some[T FullLiftLower[T]](v T) Option[T] {
+	return Option[T]{Raw: v, IsSome: true}
+}
+
+// None creates an empty [Option].
+func None[T FullLiftLower[T]]() Option[T] {
+	return Option[T]{}
+}
+
+// Unwrap returns the wrapped value. It is the zero value if the option is empty.
+func (v Option[T]) Unwrap() T {
+	return v.Raw
+}
+
+// FormatTrace implements [TraceFormatter] interface.
+func (v Option[T]) FormatTrace() string {
+	if !v.IsSome {
+		return "none"
+	}
+	return "some(" + formatTrace(v.Raw) + ")"
+}
+
+// ValueTypes implements [Value] interface.
+//
+// The option is flattened into the discriminant followed by the value.
+func (v Option[T]) ValueTypes() []ValueType {
+	return append([]ValueType{ValueTypeI32}, v.Raw.ValueTypes()...)
+}
+
+// Lift implements [Lift] interface.
+func (v Option[T]) Lift(s *Store) Option[T] {
+	payload := popRaw(s, len(v.Raw.ValueTypes()))
+	isSome := s.Stack.Pop()
+	switch isSome {
+	case 0:
+		return Option[T]{}
+	case 1:
+		return Option[T]{Raw: liftRaw(s, v.Raw, payload), IsSome: true}
+	default:
+		s.liftFailed(ErrDiscriminant, v, 0, 0)
+		return Option[T]{}
 	}
 }
 
 // Lower implements [Lower] interface.
-// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
-// To use this need to have pre-allocated linear memory into which to write the actual data.
-func (v Result[Shape, OK, Err]) Lower(s *Store) {
-	if v.DataPtr == 0 {
-		s.Error = ErrMemWrite
+func (v Option[T]) Lower(s *Store) {
+	if !v.IsSome {
+		s.Stack.Push(0)
+		for range v.Raw.ValueTypes() {
+			s.Stack.Push(0)
+		}
 		return
 	}
+	s.Stack.Push(1)
+	v.Raw.Lower(s)
+}
 
-	var isError UInt32
-	if v.IsError {
-		isError = 1
+// MemoryLift implements [MemoryLift] interface.
+func (v Option[T]) MemoryLift(s *Store, offset uint32) (Option[T], uint32) {
+	size, align := v.MemorySize(), v.MemoryAlign()
+	isSome, _ := UInt8(0).MemoryLift(s, offset)
+	switch isSome {
+	case 0:
+		return Option[T]{}, size
+	case 1:
+		raw, _ := v.Raw.MemoryLift(s, offset+align)
+		return Option[T]{Raw: raw, IsSome: true}, size
+	default:
+		s.liftFailed(ErrDiscriminant, v, offset, 1)
+		return Option[T]{}, size
+	}
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Option[T]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	size, align := v.MemorySize(), v.MemoryAlign()
+	if !v.IsSome {
+		UInt8(0).MemoryLower(s, offset)
+		return size
 	}
-	sz := isError.MemoryLower(s, v.Offset)
+	UInt8(1).MemoryLower(s, offset)
+	v.Raw.MemoryLower(s, offset+align)
+	return size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+//
+// The option is stored as the discriminant byte, the padding to the alignment of the value,
+// and the value itself.
+func (v Option[T]) MemorySize() uint32 {
+	align := v.MemoryAlign()
+	return alignTo(align+v.Raw.MemorySize(), align)
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Option[T]) MemoryAlign() uint32 {
+	return max(v.Raw.MemoryAlign(), 1)
+}
+
+// popRaw pops the given number of raw values from the stack.
+//
+// The values are returned in the order in which they were pushed.
+func popRaw(s *Store, n int) []Raw {
+	raw := make([]Raw, n)
+	for i := n - 1; i >= 0; i-- {
+		raw[i] = s.Stack.Pop()
+	}
+	return raw
+}
+
+// liftRaw lifts a value from the given raw values instead of [Store.Stack].
+func liftRaw[T Lift[T]](s *Store, v T, raw []Raw) T {
+	stack := SliceStack(raw)
+	sub := *s
+	sub.Stack = &stack
+	res := v.Lift(&sub)
+	s.Error = sub.Error
+	s.scope = sub.scope
+	return res
+}
+
+// lowerAlloc allocates memory for the data of a value lowered at the given offset.
+//
+// The data cannot be written right after the value because the next value
+// (like the next list element or record field) may be there,
+// so it fails with [ErrNoAllocator] if there is no [Store.Allocator].
+func lowerAlloc(s *Store, typ Value, offset, size, align uint32) (Addr, bool) {
+	if s.Allocator == nil {
+		s.lowerFailed(ErrNoAllocator, typ, offset, size)
+		return 0, false
+	}
+	return s.alloc(typ, size, align)
+}
+
+// allocList allocates memory for the elements of a list using [Store.Allocator].
+func allocList[T MemoryLayout](s *Store, list Value, raw []T) (Addr, bool) {
+	var elem T
+	return s.alloc(list, elem.MemorySize()*uint32(len(raw)), elem.MemoryAlign())
+}
+
+// liftPtrLen reads the pointer and the length of memory-based data, like [String],
+// stored at the given offset.
+func liftPtrLen(s *Store, typ Value, offset uint32) (ptr uint32, size uint32, ok bool) {
+	ptr, ok1 := s.Memory.ReadUint32Le(offset)
+	size, ok2 := s.Memory.ReadUint32Le(offset + 4)
+	if !ok1 || !ok2 {
+		s.liftFailed(ErrMemRead, typ, offset, 8)
+		return 0, 0, false
+	}
+	return ptr, size, true
+}
+
+// lowerPtrLen writes the pointer and the length of memory-based data, like [String],
+// at the given offset.
+func lowerPtrLen(s *Store, typ Value, offset, ptr, size uint32) bool {
+	// The length is written first, so that nothing is written
+	// if the end of the range is out of bounds.
+	ok := s.Memory.WriteUint32Le(offset+4, size) && s.Memory.WriteUint32Le(offset, ptr)
+	if !ok {
+		s.lowerFailed(ErrMemWrite, typ, offset, 8)
+	}
+	return ok
+}
+
+// liftElems reads the given number of list elements starting at the given address.
+//
+// Each element occupies [MemoryLayout.MemorySize] bytes, which includes the padding.
+// The count comes from the guest, so the whole range is checked to be in memory
+// before allocating the elements.
+func liftElems[T MemoryLiftLower[T]](s *Store, typ Value, ptr, count uint32) []T {
+	var elem T
+	size := elem.MemorySize()
+	end := uint64(ptr) + uint64(count)*uint64(size)
+	if end > uint64(ptr) {
+		ok := end <= math.MaxUint32+1
+		if ok {
+			_, ok = s.Memory.ReadUint8(uint32(end - 1))
+		}
+		if !ok {
+			s.liftFailed(ErrMemRead, typ, ptr, uint32(min(end-uint64(ptr), math.MaxUint32)))
+			return nil
+		}
+	}
+	data := make([]T, count)
+	failed := s.errorsLen()
+	for i := range data {
+		data[i], _ = elem.MemoryLift(s, ptr+uint32(i)*size)
+		// Stop on the first error, so that a bad pointer in a long list
+		// doesn't make the host record an error for every element.
+		if s.errorsLen() != failed {
+			return data
+		}
+	}
+	return data
+}
 
-	switch v.IsError {
-	case true:
-		v.Error.MemoryLower(s, v.Offset+sz)
-	case false:
-		v.OK.MemoryLower(s, v.Offset+sz)
+// lowerElems writes list elements starting at the given address.
+func lowerElems[T MemoryLiftLower[T]](s *Store, ptr uint32, data []T) {
+	var elem T
+	size := elem.MemorySize()
+	for i, v := range data {
+		v.MemoryLower(s, ptr+uint32(i)*size)
 	}
 }
 
@@ -1,6 +1,7 @@
 package wypes_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/orsinium-labs/tinytest/is"
@@ -401,3 +402,345 @@ func TestResultOKHostRef(t *testing.T) {
 	is.Equal(c, result.IsError, false)
 	is.Equal(c, result.OK.Raw, &ref)
 }
+
+func TestStringAllocator(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:     stack,
+		Memory:    wypes.NewSliceMemory(1024),
+		Allocator: &wypes.BumpAllocator{Next: 100, End: 1024},
+	}
+
+	wypes.String{Raw: "hello"}.Lower(&store)
+	wypes.String{Raw: "world"}.Lower(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, stack.Len(), 4)
+
+	second := wypes.String{}.Lift(&store)
+	first := wypes.String{}.Lift(&store)
+	is.Equal(c, first.Offset, 100)
+	is.Equal(c, first.Unwrap(), "hello")
+	is.Equal(c, second.Offset, 105)
+	is.Equal(c, second.Unwrap(), "world")
+}
+
+func TestListAllocator(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:     stack,
+		Memory:    wypes.NewSliceMemory(1024),
+		Allocator: &wypes.BumpAllocator{Next: 101, End: 1024},
+	}
+
+	data := []wypes.UInt32{1, 2, 3}
+	wypes.List[wypes.UInt32]{Raw: data}.Lower(&store)
+	list := wypes.List[wypes.UInt32]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, list.Offset, 104)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
+
+func TestListStringsAllocator(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:     stack,
+		Memory:    wypes.NewSliceMemory(1024),
+		Allocator: &wypes.BumpAllocator{Next: 64, End: 1024},
+	}
+
+	data := []string{"Hello", "beautiful", "World"}
+	wypes.ListStrings{Raw: data}.Lower(&store)
+	list := wypes.ListStrings{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
+
+func TestList_LiftOutOfBounds(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{
+		Stack:  stack,
+		Memory: wypes.NewSliceMemory(1024),
+	}
+	stack.Push(2000)  // offset
+	stack.Push(10000) // size
+	wypes.List[wypes.UInt32]{}.Lift(&store)
+	// only one error is recorded for the whole list
+	err, ok := store.Error.(*wypes.LiftError)
+	is.True(c, ok)
+	is.True(c, errors.Is(err, wypes.ErrMemRead))
+	is.Equal(c, err.Addr, 2000)
+}
+
+func TestList_LiftHugeCount(t *testing.T) {
+	c := is.NewRelaxed(t)
+	cases := []struct{ ptr, count uint64 }{
+		{8, 0xFFFFFFFF},
+		// the end of the list wraps around to the beginning of the memory
+		{0xFFFFFFF0, 8},
+	}
+	for _, tc := range cases {
+		stack := wypes.NewSliceStack(4)
+		store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+		stack.Push(tc.ptr)
+		stack.Push(tc.count)
+		list := wypes.List[wypes.UInt32]{}.Lift(&store)
+		is.True(c, errors.Is(store.Error, wypes.ErrMemRead))
+		is.Equal(c, len(list.Raw), 0)
+	}
+}
+
+func TestAllocatorOutOfMemory(t *testing.T) {
+	c := is.NewRelaxed(t)
+	store := wypes.Store{
+		Stack:     wypes.NewSliceStack(4),
+		Memory:    wypes.NewSliceMemory(1024),
+		Allocator: &wypes.BumpAllocator{Next: 64, End: 66},
+	}
+	wypes.Bytes{Raw: []byte("hello")}.Lower(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrAlloc))
+}
+
+func TestOptionSome(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+
+	wypes.Some(wypes.UInt32(13)).Lower(&store)
+	is.SliceEqual(c, *stack, []uint64{1, 13})
+	opt := wypes.Option[wypes.UInt32]{}.Lift(&store)
+	is.True(c, opt.IsSome)
+	is.Equal(c, opt.Unwrap(), 13)
+	is.Equal(c, stack.Len(), 0)
+}
+
+func TestOptionNone(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Refs: wypes.NewMapRefs()}
+
+	wypes.None[wypes.HostRef[*user]]().Lower(&store)
+	is.SliceEqual(c, *stack, []uint64{0, 0})
+	opt := wypes.Option[wypes.HostRef[*user]]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.True(c, !opt.IsSome)
+	is.Equal(c, stack.Len(), 0)
+}
+
+func TestOptionBadDiscriminant(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+	stack.Push(2)
+	stack.Push(13)
+	wypes.Option[wypes.UInt32]{}.Lift(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrDiscriminant))
+}
+
+func TestOptionMemoryLayout(t *testing.T) {
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Memory: memory}
+
+	// The payload is aligned to 8 bytes, and so is the option size.
+	opt := wypes.Some(wypes.UInt64(0x0102030405060708))
+	size := opt.MemoryLower(&store, 64)
+	is.Equal(c, size, 16)
+	raw, _ := memory.Read(64, 16)
+	is.SliceEqual(c, raw, []byte{1, 0, 0, 0, 0, 0, 0, 0, 8, 7, 6, 5, 4, 3, 2, 1})
+
+	res, size := wypes.Option[wypes.UInt64]{}.MemoryLift(&store, 64)
+	is.Equal(c, size, 16)
+	is.True(c, res.IsSome)
+	is.Equal(c, res.Unwrap(), 0x0102030405060708)
+
+	// A byte payload goes right after the discriminant.
+	size = wypes.Some(wypes.UInt8(42)).MemoryLower(&store, 128)
+	is.Equal(c, size, 2)
+	raw, _ = memory.Read(128, 2)
+	is.SliceEqual(c, raw, []byte{1, 42})
+}
+
+func TestOptionList(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+
+	data := []wypes.Option[wypes.UInt16]{
+		wypes.Some(wypes.UInt16(1)),
+		wypes.None[wypes.UInt16](),
+		wypes.Some(wypes.UInt16(3)),
+	}
+	wypes.List[wypes.Option[wypes.UInt16]]{Offset: 64, Raw: data}.Lower(&store)
+	list := wypes.List[wypes.Option[wypes.UInt16]]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
+
+func TestResultOKOption(t *testing.T) {
+	type R = wypes.Result[wypes.Option[wypes.UInt32], wypes.Option[wypes.UInt32], wypes.UInt32]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+	save := R{
+		OK:      wypes.Some(wypes.UInt32(42)),
+		Offset:  64,
+		DataPtr: 128,
+	}
+
+	save.Lower(&store)
+	store.Stack.Push(64)
+	result := R{}.Lift(&store)
+
+	is.Equal(c, result.IsError, false)
+	is.Equal(c, result.OK, wypes.Some(wypes.UInt32(42)))
+}
+
+func TestMemoryLayout(t *testing.T) {
+	c := is.NewRelaxed(t)
+	cases := []struct {
+		v     wypes.MemoryLayout
+		size  uint32
+		align uint32
+	}{
+		{wypes.Bool(false), 1, 1},
+		{wypes.UInt8(0), 1, 1},
+		{wypes.Int16(0), 2, 2},
+		{wypes.UInt32(0), 4, 4},
+		{wypes.Float64(0), 8, 8},
+		{wypes.Void{}, 0, 1},
+		{wypes.String{}, 8, 4},
+		{wypes.List[wypes.UInt64]{}, 8, 4},
+		{wypes.Option[wypes.UInt8]{}, 2, 1},
+		{wypes.Option[wypes.UInt64]{}, 16, 8},
+		{wypes.Result[wypes.String, wypes.String, wypes.UInt8]{}, 12, 4},
+		{wypes.Result[wypes.UInt64, wypes.UInt8, wypes.UInt64]{}, 16, 8},
+		{wypes.Result[wypes.UInt64, wypes.UInt8, wypes.UInt8]{}, 16, 8},
+		{wypes.Result[wypes.Void, wypes.Void, wypes.Void]{}, 1, 1},
+	}
+	for _, tc := range cases {
+		is.Equal(c, tc.v.MemorySize(), tc.size)
+		is.Equal(c, tc.v.MemoryAlign(), tc.align)
+	}
+}
+
+// The expected bytes match the memory layout of cm.Result from wasm-tools-go.
+func TestResultMemoryBytes(t *testing.T) {
+	type R = wypes.Result[wypes.UInt64, wypes.UInt64, wypes.String]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Memory: memory}
+
+	size := R{OK: 0x0102030405060708}.MemoryLower(&store, 64)
+	is.Equal(c, size, 16)
+	raw, _ := memory.Read(64, 16)
+	is.SliceEqual(c, raw, []byte{0, 0, 0, 0, 0, 0, 0, 0, 8, 7, 6, 5, 4, 3, 2, 1})
+
+	R{IsError: true, Error: wypes.String{Offset: 200, Raw: "oh"}}.MemoryLower(&store, 96)
+	raw, _ = memory.Read(96, 16)
+	is.SliceEqual(c, raw, []byte{1, 0, 0, 0, 0, 0, 0, 0, 200, 0, 0, 0, 2, 0, 0, 0})
+
+	res, size := R{}.MemoryLift(&store, 96)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, size, 16)
+	is.Equal(c, res.IsError, true)
+	is.Equal(c, res.Error.Unwrap(), "oh")
+}
+
+func TestResultBadDiscriminant(t *testing.T) {
+	type R = wypes.Result[wypes.UInt32, wypes.UInt32, wypes.UInt32]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Memory: memory}
+	memory.Write(64, []byte{2})
+	R{}.MemoryLift(&store, 64)
+	is.True(c, errors.Is(store.Error, wypes.ErrDiscriminant))
+}
+
+func TestListStride(t *testing.T) {
+	type R = wypes.Result[wypes.UInt64, wypes.UInt8, wypes.UInt64]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Stack: stack, Memory: memory}
+
+	data := []R{{OK: 3}, {IsError: true, Error: 4}}
+	wypes.List[R]{Offset: 64, Raw: data}.Lower(&store)
+	raw, _ := memory.Read(64, 32)
+	is.SliceEqual(c, raw, []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0,
+		1, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0,
+	})
+
+	list := wypes.List[R]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, len(list.Raw), 2)
+	is.Equal(c, list.Raw[0].OK, 3)
+	is.Equal(c, list.Raw[1].Error, 4)
+}
+
+func TestNestedListMemory(t *testing.T) {
+	type L = wypes.List[wypes.UInt16]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Stack: stack, Memory: memory}
+
+	data := []L{
+		{Offset: 256, Raw: []wypes.UInt16{1, 2, 3}},
+		{Offset: 512, Raw: []wypes.UInt16{4}},
+	}
+	wypes.List[L]{Offset: 64, Raw: data}.Lower(&store)
+	raw, _ := memory.Read(64, 16)
+	is.SliceEqual(c, raw, []byte{0, 1, 0, 0, 3, 0, 0, 0, 0, 2, 0, 0, 1, 0, 0, 0})
+
+	list := wypes.List[L]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Raw[0].Raw, []wypes.UInt16{1, 2, 3})
+	is.SliceEqual(c, list.Raw[1].Raw, []wypes.UInt16{4})
+}
+
+func TestListStrings_MemoryLower_NoAllocator(t *testing.T) {
+	type L = wypes.List[wypes.String]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Memory: memory}
+	list := L{Offset: 128, Raw: []wypes.String{{Raw: "hello"}, {Raw: "world"}}}
+	list.MemoryLower(&store, 64)
+	is.True(c, errors.Is(store.Error, wypes.ErrNoAllocator))
+	// the data isn't written past the element into the next one
+	data, _ := memory.Read(136, 8)
+	is.SliceEqual(c, data, make([]byte, 8))
+}
+
+func TestString_MemoryLower_Empty(t *testing.T) {
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(64)
+	store := wypes.Store{Memory: memory}
+	// empty data doesn't need memory, so it doesn't need an allocator
+	size := wypes.String{}.MemoryLower(&store, 8)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, size, 8)
+}
+
+func TestResult_Lower_DataPtr(t *testing.T) {
+	type R = wypes.Result[wypes.String, wypes.String, wypes.UInt32]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Stack: wypes.NewSliceStack(4), Memory: memory}
+	R{OK: wypes.String{Raw: "hi"}, Offset: 64, DataPtr: 128}.Lower(&store)
+	is.Err(is.Not(c), store.Error)
+	// the string header points to the data written at DataPtr
+	header, _ := memory.Read(68, 8)
+	is.SliceEqual(c, header, []byte{128, 0, 0, 0, 2, 0, 0, 0})
+	data, _ := memory.Read(128, 2)
+	is.Equal(c, string(data), "hi")
+
+	// without DataPtr, the data needs an allocator
+	store = wypes.Store{Stack: wypes.NewSliceStack(4), Memory: memory}
+	R{OK: wypes.String{Raw: "hi"}, Offset: 64}.Lower(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrNoAllocator))
+}
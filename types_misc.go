@@ -2,7 +2,7 @@ package wypes
 
 import (
 	"context"
-	"encoding/binary"
+	"fmt"
 	"math"
 	"time"
 )
@@ -37,14 +37,14 @@ func (v Bool) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (Bool) MemoryLift(s *Store, offset uint32) (Bool, uint32) {
-	raw, ok := s.Memory.Read(offset, BoolSize)
+func (v Bool) MemoryLift(s *Store, offset uint32) (Bool, uint32) {
+	raw, ok := s.Memory.ReadUint8(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, BoolSize)
 		return Bool(false), 0
 	}
 
-	return Bool(raw[0] > 0), BoolSize
+	return Bool(raw > 0), BoolSize
 }
 
 // MemoryLower implements [MemoryLower] interface.
@@ -54,15 +54,25 @@ func (v Bool) MemoryLower(s *Store, offset uint32) (length uint32) {
 		res = 1
 	}
 
-	ok := s.Memory.Write(offset, []byte{res})
+	ok := s.Memory.WriteUint8(offset, res)
 	if !ok {
-		s.Error = ErrMemRead
+		s.lowerFailed(ErrMemWrite, v, offset, BoolSize)
 		return 0
 	}
 
 	return BoolSize
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Bool) MemorySize() uint32 {
+	return BoolSize
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Bool) MemoryAlign() uint32 {
+	return BoolSize
+}
+
 // Float32 wraps [float32].
 type Float32 float32
 
@@ -91,29 +101,37 @@ func (v Float32) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (Float32) MemoryLift(s *Store, offset uint32) (Float32, uint32) {
-	raw, ok := s.Memory.Read(offset, Float32Size)
+func (v Float32) MemoryLift(s *Store, offset uint32) (Float32, uint32) {
+	raw, ok := s.Memory.ReadUint32Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, Float32Size)
 		return Float32(0), 0
 	}
 
-	return Float32(math.Float32frombits(binary.LittleEndian.Uint32(raw))), Float32Size
+	return Float32(math.Float32frombits(raw)), Float32Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Float32) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, Float32Size)
-	binary.LittleEndian.PutUint32(data, math.Float32bits(float32(v)))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint32Le(offset, math.Float32bits(float32(v)))
 	if !ok {
-		s.Error = ErrMemRead
+		s.lowerFailed(ErrMemWrite, v, offset, Float32Size)
 		return 0
 	}
 
 	return Float32Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Float32) MemorySize() uint32 {
+	return Float32Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Float32) MemoryAlign() uint32 {
+	return Float32Size
+}
+
 // Float64 wraps [float64].
 type Float64 float64
 
@@ -142,29 +160,37 @@ func (v Float64) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (Float64) MemoryLift(s *Store, offset uint32) (Float64, uint32) {
-	raw, ok := s.Memory.Read(offset, Float64Size)
+func (v Float64) MemoryLift(s *Store, offset uint32) (Float64, uint32) {
+	raw, ok := s.Memory.ReadUint64Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, Float64Size)
 		return Float64(0), 0
 	}
 
-	return Float64(math.Float32frombits(binary.LittleEndian.Uint32(raw))), Float64Size
+	return Float64(math.Float64frombits(raw)), Float64Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v Float64) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, Float64Size)
-	binary.LittleEndian.PutUint64(data, math.Float64bits(float64(v)))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint64Le(offset, math.Float64bits(float64(v)))
 	if !ok {
-		s.Error = ErrMemRead
+		s.lowerFailed(ErrMemWrite, v, offset, Float64Size)
 		return 0
 	}
 
 	return Float64Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (Float64) MemorySize() uint32 {
+	return Float64Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Float64) MemoryAlign() uint32 {
+	return Float64Size
+}
+
 // Complex64 wraps [complex64].
 type Complex64 complex64
 
@@ -271,7 +297,10 @@ func (v Time) Lower(s *Store) {
 	s.Stack.Push(Raw(time.Time(v).Unix()))
 }
 
-// Context wraps [context.Context].
+// Context wraps [context.Context] passed into the guest function call.
+//
+// With [WithCancellation], the context is also canceled
+// when the guest module is closed during the host function call.
 type Context struct{ ctx context.Context }
 
 // Unwrap returns the wrapped value.
@@ -285,11 +314,40 @@ func (Context) ValueTypes() []ValueType {
 }
 
 // Lift implements [Lift] interface.
-func (Context) Lift(s Store) Context {
+func (Context) Lift(s *Store) Context {
 	return Context{ctx: s.Context}
 }
 
+// Data is the user state of the guest module instance that called the host function.
+//
+// Like [Store], it doesn't take any values from the stack. It lifts [Store.Data]
+// which is set from [Instance.Data], for example, by [WazeroInstances].
+// If the state is not of the type T, lifting fails with [ErrDataType].
+type Data[T any] struct{ val T }
+
+// Unwrap returns the wrapped value.
+func (v Data[T]) Unwrap() T {
+	return v.val
+}
+
+// ValueTypes implements [Value] interface.
+func (Data[T]) ValueTypes() []ValueType {
+	return []ValueType{}
+}
+
+// Lift implements [Lift] interface.
+func (v Data[T]) Lift(s *Store) Data[T] {
+	val, ok := s.Data.(T)
+	if !ok {
+		s.liftFailed(fmt.Errorf("%w: got %T", ErrDataType, s.Data), v, 0, 0)
+		return Data[T]{}
+	}
+	return Data[T]{val: val}
+}
+
 // Void is a return type of a function that returns nothing.
+//
+// It can be used as a result of both host-defined ([H0]) and guest-defined ([G0]) functions.
 type Void struct{}
 
 // ValueTypes implements [Value] interface.
@@ -297,13 +355,39 @@ func (Void) ValueTypes() []ValueType {
 	return []ValueType{}
 }
 
+// Lift implements [Lift] interface.
+func (Void) Lift(s *Store) Void {
+	return Void{}
+}
+
 // Lower implements [Lower] interface.
 func (Void) Lower(s *Store) {}
 
+// MemoryLift implements [MemoryLift] interface.
+func (Void) MemoryLift(s *Store, offset uint32) (Void, uint32) {
+	return Void{}, 0
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (Void) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return 0
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (Void) MemorySize() uint32 {
+	return 0
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Void) MemoryAlign() uint32 {
+	return 1
+}
+
 // Pair wraps two values of arbitrary types.
 //
 // You can combine multiple pairs to pass more than 2 values at once.
 // All values are passed through the stack, not memory.
+// If the values also need to be passed through memory, use [Tuple2] instead.
 type Pair[L LiftLower[L], R LiftLower[R]] struct {
 	Left  L
 	Right R
@@ -366,29 +450,18 @@ func (v HostRef[T]) Drop() {
 	}
 }
 
+// isHostRef implements hostRefValue interface.
+func (HostRef[T]) isHostRef() {}
+
 // ValueTypes implements [Value] interface.
 func (HostRef[T]) ValueTypes() []ValueType {
 	return []ValueType{ValueTypeI32}
 }
 
 // Lift implements [Lift] interface.
-func (HostRef[T]) Lift(s *Store) HostRef[T] {
+func (v HostRef[T]) Lift(s *Store) HostRef[T] {
 	index := uint32(s.Stack.Pop())
-	var def T
-	raw, found := s.Refs.Get(index, def)
-	if !found {
-		s.Error = ErrRefNotFound
-	}
-	cast, ok := raw.(T)
-	if found && !ok {
-		s.Error = ErrRefCast
-		cast = def
-	}
-	return HostRef[T]{
-		Raw:   cast,
-		index: index,
-		refs:  s.Refs,
-	}
+	return v.get(s, index)
 }
 
 // Lower implements [Lower] interface.
@@ -404,28 +477,24 @@ func (v HostRef[T]) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLifter] interface.
-func (HostRef[T]) MemoryLift(s *Store, offset uint32) (HostRef[T], uint32) {
-	i, ok := s.Memory.Read(offset, uInt32Size)
-	var def T
+func (v HostRef[T]) MemoryLift(s *Store, offset uint32) (HostRef[T], uint32) {
+	i, ok := s.Memory.ReadUint32Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uInt32Size)
 		return HostRef[T]{}, 0
 	}
-	index := binary.LittleEndian.Uint32(i)
-	raw, found := s.Refs.Get(index, def)
-	if !found {
-		s.Error = ErrRefNotFound
-	}
-	cast, ok := raw.(T)
-	if found && !ok {
-		s.Error = ErrRefCast
-		cast = def
-	}
+	index := i
+	return v.get(s, index), uInt32Size
+}
+
+// get resolves the reference with the given index in [Refs].
+func (v HostRef[T]) get(s *Store, index uint32) HostRef[T] {
+	cast, _ := lookupRef[T](s, v, index)
 	return HostRef[T]{
 		Raw:   cast,
 		index: index,
 		refs:  s.Refs,
-	}, uInt32Size
+	}
 }
 
 // MemoryLower implements [MemoryLower] interface.
@@ -438,13 +507,21 @@ func (v HostRef[T]) MemoryLower(s *Store, offset uint32) (length uint32) {
 		s.Refs.Set(v.index, v.Raw)
 	}
 
-	data := make([]byte, uInt32Size)
-	binary.LittleEndian.PutUint32(data, uint32(index))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint32Le(offset, uint32(index))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uInt32Size)
 		return 0
 	}
 
 	return uInt32Size
 }
+
+// MemorySize implements [MemoryLayout] interface.
+func (HostRef[T]) MemorySize() uint32 {
+	return uInt32Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (HostRef[T]) MemoryAlign() uint32 {
+	return uInt32Size
+}
@@ -0,0 +1,586 @@
+package wypes
+
+// Field is a field of a [Record] or a tuple.
+//
+// Use [FieldOf] to create a Field.
+type Field interface {
+	Value
+	MemoryLayout
+	liftField(s *Store)
+	lowerField(s *Store)
+	memoryLiftField(s *Store, offset uint32)
+	memoryLowerField(s *Store, offset uint32)
+}
+
+// FieldOf creates a [Field] that reads and writes the value pointed by ptr.
+func FieldOf[T FullLiftLower[T]](ptr *T) Field {
+	return field[T]{ptr: ptr}
+}
+
+type field[T FullLiftLower[T]] struct {
+	ptr *T
+}
+
+func (f field[T]) ValueTypes() []ValueType {
+	return (*f.ptr).ValueTypes()
+}
+
+func (f field[T]) MemorySize() uint32 {
+	return (*f.ptr).MemorySize()
+}
+
+func (f field[T]) MemoryAlign() uint32 {
+	return (*f.ptr).MemoryAlign()
+}
+
+func (f field[T]) liftField(s *Store) {
+	*f.ptr = (*f.ptr).Lift(s)
+}
+
+func (f field[T]) lowerField(s *Store) {
+	(*f.ptr).Lower(s)
+}
+
+func (f field[T]) memoryLiftField(s *Store, offset uint32) {
+	*f.ptr, _ = (*f.ptr).MemoryLift(s, offset)
+}
+
+func (f field[T]) memoryLowerField(s *Store, offset uint32) {
+	(*f.ptr).MemoryLower(s, offset)
+}
+
+// RecordFields is implemented by pointers to structs that can be used as a [Record].
+type RecordFields interface {
+	// Fields returns all the fields of the record in order.
+	Fields() []Field
+}
+
+// Record wraps a struct describing a component model record.
+//
+// The pointer to the struct must implement [RecordFields]. For example:
+//
+//	type Point struct {
+//		X wypes.Float32
+//		Y wypes.Float32
+//	}
+//
+//	func (p *Point) Fields() []wypes.Field {
+//		return []wypes.Field{wypes.FieldOf(&p.X), wypes.FieldOf(&p.Y)}
+//	}
+//
+// Then Record[Point, *Point] can be used as a host function argument or result,
+// or nested inside of [List], [Result], and other records.
+type Record[T any, P interface {
+	*T
+	RecordFields
+}] struct {
+	Raw T
+}
+
+// Unwrap returns the wrapped value.
+func (v Record[T, P]) Unwrap() T {
+	return v.Raw
+}
+
+// ValueTypes implements [Value] interface.
+func (v Record[T, P]) ValueTypes() []ValueType {
+	return fieldsValueTypes(P(&v.Raw).Fields())
+}
+
+// Lift implements [Lift] interface.
+func (Record[T, P]) Lift(s *Store) Record[T, P] {
+	var res Record[T, P]
+	liftFields(s, P(&res.Raw).Fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Record[T, P]) Lower(s *Store) {
+	lowerFields(s, P(&v.Raw).Fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Record[T, P]) MemoryLift(s *Store, offset uint32) (Record[T, P], uint32) {
+	var res Record[T, P]
+	size := memoryLiftFields(s, P(&res.Raw).Fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Record[T, P]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, P(&v.Raw).Fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Record[T, P]) MemorySize() uint32 {
+	size, _ := fieldsLayout(P(&v.Raw).Fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Record[T, P]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(P(&v.Raw).Fields(), nil)
+	return align
+}
+
+// fieldsValueTypes returns the flattened value types of all the fields.
+func fieldsValueTypes(fields []Field) []ValueType {
+	types := []ValueType{}
+	for _, f := range fields {
+		types = append(types, f.ValueTypes()...)
+	}
+	return types
+}
+
+// liftFields lifts the fields from the stack.
+//
+// The last field is on the top of the stack, so the fields are lifted in reverse order.
+func liftFields(s *Store, fields []Field) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		fields[i].liftField(s)
+	}
+}
+
+// lowerFields lowers the fields to the stack.
+func lowerFields(s *Store, fields []Field) {
+	for _, f := range fields {
+		f.lowerField(s)
+	}
+}
+
+// memoryLiftFields reads the fields from the memory and returns the size of the record.
+func memoryLiftFields(s *Store, fields []Field, offset uint32) uint32 {
+	offsets := make([]uint32, len(fields))
+	size, _ := fieldsLayout(fields, offsets)
+	for i, f := range fields {
+		f.memoryLiftField(s, offset+offsets[i])
+	}
+	return size
+}
+
+// memoryLowerFields writes the fields into the memory and returns the size of the record.
+func memoryLowerFields(s *Store, fields []Field, offset uint32) uint32 {
+	offsets := make([]uint32, len(fields))
+	size, _ := fieldsLayout(fields, offsets)
+	for i, f := range fields {
+		f.memoryLowerField(s, offset+offsets[i])
+	}
+	return size
+}
+
+// fieldsLayout returns the size and the alignment of a record with fields of the given layouts.
+//
+// If offsets is not nil, it is filled with the offset of each field relative to the start of the record.
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#alignment
+func fieldsLayout[F MemoryLayout](fields []F, offsets []uint32) (size uint32, align uint32) {
+	align = 1
+	for i, f := range fields {
+		fieldAlign := f.MemoryAlign()
+		size = alignTo(size, fieldAlign)
+		if offsets != nil {
+			offsets[i] = size
+		}
+		size += f.MemorySize()
+		align = max(align, fieldAlign)
+	}
+	return alignTo(size, align), align
+}
+
+// Tuple2 wraps a component model tuple with 2 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple2[T0 FullLiftLower[T0], T1 FullLiftLower[T1]] struct {
+	F0 T0
+	F1 T1
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple2[T0, T1]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple2[T0, T1]) Lift(s *Store) Tuple2[T0, T1] {
+	var res Tuple2[T0, T1]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple2[T0, T1]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple2[T0, T1]) MemoryLift(s *Store, offset uint32) (Tuple2[T0, T1], uint32) {
+	var res Tuple2[T0, T1]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple2[T0, T1]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple2[T0, T1]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple2[T0, T1]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple2[T0, T1]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1)}
+}
+
+// Tuple3 wraps a component model tuple with 3 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple3[T0 FullLiftLower[T0], T1 FullLiftLower[T1], T2 FullLiftLower[T2]] struct {
+	F0 T0
+	F1 T1
+	F2 T2
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple3[T0, T1, T2]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple3[T0, T1, T2]) Lift(s *Store) Tuple3[T0, T1, T2] {
+	var res Tuple3[T0, T1, T2]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple3[T0, T1, T2]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple3[T0, T1, T2]) MemoryLift(s *Store, offset uint32) (Tuple3[T0, T1, T2], uint32) {
+	var res Tuple3[T0, T1, T2]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple3[T0, T1, T2]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple3[T0, T1, T2]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple3[T0, T1, T2]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple3[T0, T1, T2]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1), FieldOf(&v.F2)}
+}
+
+// Tuple4 wraps a component model tuple with 4 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple4[T0 FullLiftLower[T0], T1 FullLiftLower[T1], T2 FullLiftLower[T2], T3 FullLiftLower[T3]] struct {
+	F0 T0
+	F1 T1
+	F2 T2
+	F3 T3
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple4[T0, T1, T2, T3]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple4[T0, T1, T2, T3]) Lift(s *Store) Tuple4[T0, T1, T2, T3] {
+	var res Tuple4[T0, T1, T2, T3]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple4[T0, T1, T2, T3]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple4[T0, T1, T2, T3]) MemoryLift(s *Store, offset uint32) (Tuple4[T0, T1, T2, T3], uint32) {
+	var res Tuple4[T0, T1, T2, T3]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple4[T0, T1, T2, T3]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple4[T0, T1, T2, T3]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple4[T0, T1, T2, T3]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple4[T0, T1, T2, T3]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1), FieldOf(&v.F2), FieldOf(&v.F3)}
+}
+
+// Tuple5 wraps a component model tuple with 5 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple5[T0 FullLiftLower[T0], T1 FullLiftLower[T1], T2 FullLiftLower[T2], T3 FullLiftLower[T3], T4 FullLiftLower[T4]] struct {
+	F0 T0
+	F1 T1
+	F2 T2
+	F3 T3
+	F4 T4
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple5[T0, T1, T2, T3, T4]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple5[T0, T1, T2, T3, T4]) Lift(s *Store) Tuple5[T0, T1, T2, T3, T4] {
+	var res Tuple5[T0, T1, T2, T3, T4]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple5[T0, T1, T2, T3, T4]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple5[T0, T1, T2, T3, T4]) MemoryLift(s *Store, offset uint32) (Tuple5[T0, T1, T2, T3, T4], uint32) {
+	var res Tuple5[T0, T1, T2, T3, T4]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple5[T0, T1, T2, T3, T4]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple5[T0, T1, T2, T3, T4]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple5[T0, T1, T2, T3, T4]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple5[T0, T1, T2, T3, T4]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1), FieldOf(&v.F2), FieldOf(&v.F3), FieldOf(&v.F4)}
+}
+
+// Tuple6 wraps a component model tuple with 6 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple6[T0 FullLiftLower[T0], T1 FullLiftLower[T1], T2 FullLiftLower[T2], T3 FullLiftLower[T3], T4 FullLiftLower[T4], T5 FullLiftLower[T5]] struct {
+	F0 T0
+	F1 T1
+	F2 T2
+	F3 T3
+	F4 T4
+	F5 T5
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple6[T0, T1, T2, T3, T4, T5]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple6[T0, T1, T2, T3, T4, T5]) Lift(s *Store) Tuple6[T0, T1, T2, T3, T4, T5] {
+	var res Tuple6[T0, T1, T2, T3, T4, T5]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple6[T0, T1, T2, T3, T4, T5]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple6[T0, T1, T2, T3, T4, T5]) MemoryLift(s *Store, offset uint32) (Tuple6[T0, T1, T2, T3, T4, T5], uint32) {
+	var res Tuple6[T0, T1, T2, T3, T4, T5]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple6[T0, T1, T2, T3, T4, T5]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple6[T0, T1, T2, T3, T4, T5]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple6[T0, T1, T2, T3, T4, T5]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple6[T0, T1, T2, T3, T4, T5]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1), FieldOf(&v.F2), FieldOf(&v.F3), FieldOf(&v.F4), FieldOf(&v.F5)}
+}
+
+// Tuple7 wraps a component model tuple with 7 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple7[T0 FullLiftLower[T0], T1 FullLiftLower[T1], T2 FullLiftLower[T2], T3 FullLiftLower[T3], T4 FullLiftLower[T4], T5 FullLiftLower[T5], T6 FullLiftLower[T6]] struct {
+	F0 T0
+	F1 T1
+	F2 T2
+	F3 T3
+	F4 T4
+	F5 T5
+	F6 T6
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple7[T0, T1, T2, T3, T4, T5, T6]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple7[T0, T1, T2, T3, T4, T5, T6]) Lift(s *Store) Tuple7[T0, T1, T2, T3, T4, T5, T6] {
+	var res Tuple7[T0, T1, T2, T3, T4, T5, T6]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple7[T0, T1, T2, T3, T4, T5, T6]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple7[T0, T1, T2, T3, T4, T5, T6]) MemoryLift(s *Store, offset uint32) (Tuple7[T0, T1, T2, T3, T4, T5, T6], uint32) {
+	var res Tuple7[T0, T1, T2, T3, T4, T5, T6]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple7[T0, T1, T2, T3, T4, T5, T6]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple7[T0, T1, T2, T3, T4, T5, T6]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple7[T0, T1, T2, T3, T4, T5, T6]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple7[T0, T1, T2, T3, T4, T5, T6]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1), FieldOf(&v.F2), FieldOf(&v.F3), FieldOf(&v.F4), FieldOf(&v.F5), FieldOf(&v.F6)}
+}
+
+// Tuple8 wraps a component model tuple with 8 values.
+//
+// On the stack, the values are flattened one after another.
+// In memory, each value is aligned the same way as a field of a [Record].
+type Tuple8[T0 FullLiftLower[T0], T1 FullLiftLower[T1], T2 FullLiftLower[T2], T3 FullLiftLower[T3], T4 FullLiftLower[T4], T5 FullLiftLower[T5], T6 FullLiftLower[T6], T7 FullLiftLower[T7]] struct {
+	F0 T0
+	F1 T1
+	F2 T2
+	F3 T3
+	F4 T4
+	F5 T5
+	F6 T6
+	F7 T7
+}
+
+// ValueTypes implements [Value] interface.
+func (v Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) ValueTypes() []ValueType {
+	return fieldsValueTypes(v.fields())
+}
+
+// Lift implements [Lift] interface.
+func (Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) Lift(s *Store) Tuple8[T0, T1, T2, T3, T4, T5, T6, T7] {
+	var res Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]
+	liftFields(s, res.fields())
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) Lower(s *Store) {
+	lowerFields(s, v.fields())
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) MemoryLift(s *Store, offset uint32) (Tuple8[T0, T1, T2, T3, T4, T5, T6, T7], uint32) {
+	var res Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]
+	size := memoryLiftFields(s, res.fields(), offset)
+	return res, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	return memoryLowerFields(s, v.fields(), offset)
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) MemorySize() uint32 {
+	size, _ := fieldsLayout(v.fields(), nil)
+	return size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) MemoryAlign() uint32 {
+	_, align := fieldsLayout(v.fields(), nil)
+	return align
+}
+
+func (v *Tuple8[T0, T1, T2, T3, T4, T5, T6, T7]) fields() []Field {
+	return []Field{FieldOf(&v.F0), FieldOf(&v.F1), FieldOf(&v.F2), FieldOf(&v.F3), FieldOf(&v.F4), FieldOf(&v.F5), FieldOf(&v.F6), FieldOf(&v.F7)}
+}
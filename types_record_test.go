@@ -0,0 +1,126 @@
+package wypes_test
+
+import (
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+type point struct {
+	X wypes.Float32
+	Y wypes.Float32
+}
+
+func (p *point) Fields() []wypes.Field {
+	return []wypes.Field{wypes.FieldOf(&p.X), wypes.FieldOf(&p.Y)}
+}
+
+type padded struct {
+	A wypes.UInt8
+	B wypes.UInt32
+	C wypes.UInt16
+}
+
+func (p *padded) Fields() []wypes.Field {
+	return []wypes.Field{wypes.FieldOf(&p.A), wypes.FieldOf(&p.B), wypes.FieldOf(&p.C)}
+}
+
+func TestRecordStack(t *testing.T) {
+	type P = wypes.Record[point, *point]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+
+	p := P{Raw: point{X: 1.5, Y: -2}}
+	is.SliceEqual(c, p.ValueTypes(), []wypes.ValueType{wypes.ValueTypeF32, wypes.ValueTypeF32})
+	p.Lower(&store)
+	is.Equal(c, len(*stack), 2)
+	res := P{}.Lift(&store)
+	is.Equal(c, res.Unwrap(), p.Raw)
+}
+
+func TestRecordMemory(t *testing.T) {
+	type P = wypes.Record[padded, *padded]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Memory: memory}
+
+	p := P{Raw: padded{A: 1, B: 2, C: 3}}
+	is.Equal(c, p.MemorySize(), 12)
+	is.Equal(c, p.MemoryAlign(), 4)
+	size := p.MemoryLower(&store, 64)
+	is.Equal(c, size, 12)
+	raw, _ := memory.Read(64, 12)
+	is.SliceEqual(c, raw, []byte{1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0})
+
+	res, size := P{}.MemoryLift(&store, 64)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, size, 12)
+	is.Equal(c, res.Unwrap(), p.Raw)
+}
+
+func TestRecordList(t *testing.T) {
+	type P = wypes.Record[point, *point]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+
+	data := []P{
+		{Raw: point{X: 1, Y: 2}},
+		{Raw: point{X: 3, Y: 4}},
+	}
+	wypes.List[P]{Offset: 64, Raw: data}.Lower(&store)
+	list := wypes.List[P]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
+
+func TestTuple(t *testing.T) {
+	type T = wypes.Tuple3[wypes.UInt8, wypes.UInt64, wypes.Bool]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	memory := wypes.NewSliceMemory(1024)
+	store := wypes.Store{Stack: stack, Memory: memory}
+
+	v := T{F0: 7, F1: 0x0102030405060708, F2: true}
+	is.SliceEqual(c, v.ValueTypes(), []wypes.ValueType{
+		wypes.ValueTypeI32, wypes.ValueTypeI64, wypes.ValueTypeI32,
+	})
+	v.Lower(&store)
+	is.SliceEqual(c, *stack, []uint64{7, 0x0102030405060708, 1})
+	is.Equal(c, T{}.Lift(&store), v)
+
+	is.Equal(c, v.MemorySize(), 24)
+	is.Equal(c, v.MemoryAlign(), 8)
+	v.MemoryLower(&store, 64)
+	raw, _ := memory.Read(64, 24)
+	is.SliceEqual(c, raw, []byte{
+		7, 0, 0, 0, 0, 0, 0, 0,
+		8, 7, 6, 5, 4, 3, 2, 1,
+		1, 0, 0, 0, 0, 0, 0, 0,
+	})
+	res, _ := T{}.MemoryLift(&store, 64)
+	is.Equal(c, res, v)
+}
+
+func TestResultOKTuple(t *testing.T) {
+	type T = wypes.Tuple2[wypes.UInt16, wypes.String]
+	type R = wypes.Result[T, T, wypes.UInt8]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+
+	save := R{
+		OK:      T{F0: 42, F1: wypes.String{Offset: 256, Raw: "hi"}},
+		Offset:  64,
+		DataPtr: 128,
+	}
+	save.Lower(&store)
+	store.Stack.Push(64)
+	result := R{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, result.IsError, false)
+	is.Equal(c, result.OK.F0, 42)
+	is.Equal(c, result.OK.F1.Unwrap(), "hi")
+}
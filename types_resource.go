@@ -0,0 +1,306 @@
+package wypes
+
+import "fmt"
+
+// Own is an owned handle of a component model resource stored in [Refs].
+//
+// Lifting an owned handle transfers the ownership of the resource from the guest
+// to the host: the resource is removed from [Refs] and the handle becomes invalid
+// for the guest. That happens only when the host function is called, so if lifting
+// another param fails, the resource stays with the guest. Lowering an owned handle transfers the ownership back to the guest:
+// the resource is put into [Refs] and the guest gets a new handle for it.
+//
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#handle-types
+type Own[T any] struct {
+	Raw T
+}
+
+// NewOwn creates an owned handle for the given resource.
+func NewOwn[T any](v T) Own[T] {
+	return Own[T]{Raw: v}
+}
+
+// Unwrap returns the wrapped resource.
+func (v Own[T]) Unwrap() T {
+	return v.Raw
+}
+
+// ValueTypes implements [Value] interface.
+func (Own[T]) ValueTypes() []ValueType {
+	return []ValueType{ValueTypeI32}
+}
+
+// Lift implements [Lift] interface.
+func (v Own[T]) Lift(s *Store) Own[T] {
+	index := uint32(s.Stack.Pop())
+	return v.take(s, index)
+}
+
+// Lower implements [Lower] interface.
+func (v Own[T]) Lower(s *Store) {
+	s.Stack.Push(Raw(s.Refs.Put(v.Raw)))
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Own[T]) MemoryLift(s *Store, offset uint32) (Own[T], uint32) {
+	index, ok := liftHandle(s, v, offset)
+	if !ok {
+		return Own[T]{}, uInt32Size
+	}
+	return v.take(s, index), uInt32Size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Own[T]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	lowerHandle(s, v, offset, s.Refs.Put(v.Raw))
+	return uInt32Size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (Own[T]) MemorySize() uint32 {
+	return uInt32Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Own[T]) MemoryAlign() uint32 {
+	return uInt32Size
+}
+
+// take resolves the resource with the given handle in [Refs].
+//
+// The resource is removed from [Refs] only when the host function is actually called,
+// so that it is not lost if lifting another param fails.
+func (v Own[T]) take(s *Store, index uint32) Own[T] {
+	scope := s.callScope()
+	for _, owned := range scope.owned {
+		if owned == index {
+			s.liftFailed(fmt.Errorf("%w: %d", ErrRefNotFound, index), v, 0, 0)
+			return Own[T]{}
+		}
+	}
+	raw, ok := lookupRef[T](s, v, index)
+	if ok {
+		scope.owned = append(scope.owned, index)
+	}
+	return Own[T]{Raw: raw}
+}
+
+// Borrow is a borrowed handle of a component model resource stored in [Refs].
+//
+// Lifting a borrowed handle doesn't change the ownership of the resource.
+// The borrow is valid only until the host function that lifted it returns.
+// Using it after that is a bug: [Borrow.Unwrap] panics with [ErrBorrowEnded]
+// and lowering it fails with [LowerError].
+type Borrow[T any] struct {
+	raw   T
+	index uint32
+	scope *callScope
+}
+
+// Unwrap returns the borrowed resource.
+//
+// Panics with [ErrBorrowEnded] if the host function call that lifted the borrow has returned.
+func (v Borrow[T]) Unwrap() T {
+	if !v.Valid() {
+		panic(ErrBorrowEnded)
+	}
+	return v.raw
+}
+
+// Valid returns false if the host function call that lifted the borrow has returned.
+//
+// The zero value, which is also returned when lifting fails, is not valid.
+func (v Borrow[T]) Valid() bool {
+	return v.scope != nil && !v.scope.ended
+}
+
+// ValueTypes implements [Value] interface.
+func (Borrow[T]) ValueTypes() []ValueType {
+	return []ValueType{ValueTypeI32}
+}
+
+// Lift implements [Lift] interface.
+func (v Borrow[T]) Lift(s *Store) Borrow[T] {
+	index := uint32(s.Stack.Pop())
+	return v.borrow(s, index)
+}
+
+// Lower implements [Lower] interface.
+//
+// Only borrows lifted in the current call can be lowered.
+// Lowering an ended borrow fails with [ErrBorrowEnded].
+func (v Borrow[T]) Lower(s *Store) {
+	if !v.Valid() {
+		s.lowerFailed(ErrBorrowEnded, v, 0, 0)
+	}
+	s.Stack.Push(Raw(v.index))
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Borrow[T]) MemoryLift(s *Store, offset uint32) (Borrow[T], uint32) {
+	index, ok := liftHandle(s, v, offset)
+	if !ok {
+		return Borrow[T]{}, uInt32Size
+	}
+	return v.borrow(s, index), uInt32Size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Borrow[T]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	if !v.Valid() {
+		s.lowerFailed(ErrBorrowEnded, v, offset, uInt32Size)
+		return uInt32Size
+	}
+	lowerHandle(s, v, offset, v.index)
+	return uInt32Size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (Borrow[T]) MemorySize() uint32 {
+	return uInt32Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (Borrow[T]) MemoryAlign() uint32 {
+	return uInt32Size
+}
+
+// borrow resolves the resource with the given handle in [Refs] without removing it.
+func (v Borrow[T]) borrow(s *Store, index uint32) Borrow[T] {
+	raw, ok := lookupRef[T](s, v, index)
+	if !ok {
+		return Borrow[T]{}
+	}
+	return Borrow[T]{raw: raw, index: index, scope: s.callScope()}
+}
+
+// callScope tracks the lifetime of a host function call.
+type callScope struct {
+	ended bool
+
+	// owned are the handles of [Own] values lifted in the call
+	// that are not yet removed from [Refs].
+	owned []uint32
+}
+
+// callScope returns the scope of the current host function call, creating it if needed.
+func (s *Store) callScope() *callScope {
+	if s.scope == nil {
+		s.scope = &callScope{}
+	}
+	return s.scope
+}
+
+// commitLift is called when all the values are lifted.
+//
+// If lifting failed, it returns false and the host function must not be called.
+// Otherwise, the resources lifted as [Own] are removed from [Refs],
+// transferring the ownership to the host.
+func (s *Store) commitLift() bool {
+	var owned []uint32
+	if s.scope != nil {
+		owned = s.scope.owned
+		s.scope.owned = nil
+	}
+	if s.Error != nil {
+		return false
+	}
+	for _, index := range owned {
+		s.Refs.Drop(index)
+	}
+	return true
+}
+
+// endCall marks the end of the host function call.
+//
+// All [Borrow] values lifted during the call become invalid.
+func (s *Store) endCall() {
+	if s.scope != nil {
+		s.scope.ended = true
+		s.scope = nil
+	}
+}
+
+// lookupRef resolves the reference with the given index in [Refs].
+func lookupRef[T any](s *Store, typ Value, index uint32) (T, bool) {
+	var def T
+	raw, found := s.Refs.Get(index, def)
+	if !found {
+		s.liftFailed(fmt.Errorf("%w: %d", ErrRefNotFound, index), typ, 0, 0)
+		return def, false
+	}
+	cast, ok := raw.(T)
+	if !ok {
+		s.liftFailed(fmt.Errorf("%w: %T", ErrRefCast, raw), typ, 0, 0)
+		return def, false
+	}
+	return cast, true
+}
+
+// liftHandle reads a resource handle from the memory.
+func liftHandle(s *Store, typ Value, offset uint32) (uint32, bool) {
+	index, ok := s.Memory.ReadUint32Le(offset)
+	if !ok {
+		s.liftFailed(ErrMemRead, typ, offset, uInt32Size)
+		return 0, false
+	}
+	return index, true
+}
+
+// lowerHandle writes a resource handle into the memory.
+func lowerHandle(s *Store, typ Value, offset, index uint32) {
+	ok := s.Memory.WriteUint32Le(offset, index)
+	if !ok {
+		s.lowerFailed(ErrMemWrite, typ, offset, uInt32Size)
+	}
+}
+
+// Resource describes a component model resource type implemented by the host.
+//
+// Use [Resource.Define] to add the resource functions into a [Module].
+// The constructor should return [Own] and the methods should accept [Borrow]
+// as the first argument. For example:
+//
+//	wypes.Resource[*File]{
+//		Name:        "file",
+//		Constructor: wypes.H1(openFile),
+//		Methods: map[string]wypes.HostFunc{
+//			"read": wypes.H2(readFile),
+//		},
+//		Destructor: func(f *File) { f.Close() },
+//	}.Define(module)
+type Resource[T any] struct {
+	// Name is the name of the resource type in WIT.
+	Name string
+
+	// Constructor defines the "[constructor]name" function if not empty.
+	Constructor HostFunc
+
+	// Methods defines the "[method]name.method" functions.
+	Methods map[string]HostFunc
+
+	// Statics defines the "[static]name.function" functions.
+	Statics map[string]HostFunc
+
+	// Destructor is called when the guest drops an owned handle of the resource.
+	Destructor func(T)
+}
+
+// Define adds the resource functions, including "[resource-drop]name", into the module.
+func (r Resource[T]) Define(m Module) {
+	if r.Constructor.Call != nil {
+		m["[constructor]"+r.Name] = r.Constructor
+	}
+	for name, f := range r.Methods {
+		m["[method]"+r.Name+"."+name] = f
+	}
+	for name, f := range r.Statics {
+		m["[static]"+r.Name+"."+name] = f
+	}
+	m["[resource-drop]"+r.Name] = H1(func(own Own[T]) Void {
+		if r.Destructor != nil {
+			r.Destructor(own.Raw)
+		}
+		return Void{}
+	})
+}
@@ -0,0 +1,147 @@
+package wypes_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func TestOwnLift(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	refs := wypes.NewMapRefs()
+	store := wypes.Store{Stack: stack, Refs: refs}
+
+	handle := refs.Put("hello")
+	stack.Push(uint64(handle))
+	own := wypes.Own[string]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, own.Unwrap(), "hello")
+	// the ownership is transferred only when the host function is called
+	_, found := refs.Get(handle, nil)
+	is.True(c, found)
+
+	// the handle cannot be lifted twice
+	stack.Push(uint64(handle))
+	wypes.Own[string]{}.Lift(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrRefNotFound))
+}
+
+func TestOwnLift_Call(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var got string
+	f := wypes.H2(func(own wypes.Own[string], s wypes.String) wypes.Void {
+		got = own.Unwrap() + s.Unwrap()
+		return wypes.Void{}
+	})
+	mem := wypes.NewSliceMemory(64)
+	mem.Write(8, []byte("!"))
+	refs := wypes.NewMapRefs()
+	handle := refs.Put("hello")
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: mem, Refs: refs}
+
+	// lifting the string fails, so the resource stays with the guest
+	stack.Push(uint64(handle))
+	stack.Push(1 << 20)
+	stack.Push(1)
+	f.Call(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrMemRead))
+	is.Equal(c, got, "")
+	_, found := refs.Get(handle, nil)
+	is.True(c, found)
+
+	// the host function is called, so the ownership is transferred to the host
+	store.Error = nil
+	stack.Push(uint64(handle))
+	stack.Push(8)
+	stack.Push(1)
+	f.Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, got, "hello!")
+	_, found = refs.Get(handle, nil)
+	is.True(c, !found)
+}
+
+func TestOwnLower(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	refs := wypes.NewMapRefs()
+	store := wypes.Store{Stack: stack, Refs: refs}
+
+	wypes.NewOwn("hello").Lower(&store)
+	handle := uint32(stack.Pop())
+	val, found := refs.Get(handle, nil)
+	is.True(c, found)
+	is.Equal(c, val, any("hello"))
+}
+
+func TestBorrowLift(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	refs := wypes.NewMapRefs()
+	store := wypes.Store{Stack: stack, Refs: refs}
+
+	handle := refs.Put(42)
+	stack.Push(uint64(handle))
+	b := wypes.Borrow[int]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.True(c, b.Valid())
+	is.Equal(c, b.Unwrap(), 42)
+	_, found := refs.Get(handle, nil)
+	is.True(c, found)
+
+	stack.Push(uint64(handle))
+	b2 := wypes.Borrow[string]{}.Lift(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrRefCast))
+	is.True(c, !b2.Valid())
+	is.True(c, !wypes.Borrow[int]{}.Valid())
+}
+
+func TestOwnMemory(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	refs := wypes.NewMapRefs()
+	store := wypes.Store{Stack: stack, Refs: refs, Memory: wypes.NewSliceMemory(1024)}
+
+	data := []wypes.Own[string]{wypes.NewOwn("a"), wypes.NewOwn("b")}
+	wypes.List[wypes.Own[string]]{Offset: 64, Raw: data}.Lower(&store)
+	list := wypes.List[wypes.Own[string]]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
+
+func TestResourceDefine(t *testing.T) {
+	c := is.NewRelaxed(t)
+	dropped := ""
+	m := wypes.Module{}
+	wypes.Resource[string]{
+		Name: "file",
+		Constructor: wypes.H1(func(name wypes.String) wypes.Own[string] {
+			return wypes.NewOwn(name.Unwrap())
+		}),
+		Methods: map[string]wypes.HostFunc{
+			"size": wypes.H1(func(f wypes.Borrow[string]) wypes.UInt32 {
+				return wypes.UInt32(len(f.Unwrap()))
+			}),
+		},
+		Destructor: func(name string) { dropped = name },
+	}.Define(m)
+	is.Equal(c, len(m), 3)
+	_, ok := m["[constructor]file"]
+	is.True(c, ok)
+	_, ok = m["[method]file.size"]
+	is.True(c, ok)
+
+	refs := wypes.NewMapRefs()
+	store := wypes.Store{Stack: wypes.NewSliceStack(4), Refs: refs}
+	handle := refs.Put("a.txt")
+	store.Stack.Push(uint64(handle))
+	m["[resource-drop]file"].Call(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, dropped, "a.txt")
+	_, found := refs.Get(handle, nil)
+	is.True(c, !found)
+}
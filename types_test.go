@@ -1,6 +1,8 @@
 package wypes_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/orsinium-labs/tinytest/is"
@@ -83,6 +85,40 @@ func TestRoundtripPair(t *testing.T) {
 	t.Run("Pair", testRoundtripPair[wypes.Pair[wypes.Int16, wypes.Int32]])
 }
 
+func testMemoryRoundtrip[T interface {
+	wypes.MemoryLiftLower[T]
+	comparable
+}](val T) func(t *testing.T) {
+	return func(t *testing.T) {
+		c := is.NewRelaxed(t)
+		store := wypes.Store{Memory: wypes.NewSliceMemory(32)}
+		var res T
+		var lowered, lifted uint32
+		allocs := testing.AllocsPerRun(10, func() {
+			lowered = val.MemoryLower(&store, 3)
+			res, lifted = res.MemoryLift(&store, 3)
+		})
+		is.Err(is.Not(c), store.Error)
+		is.Equal(c, allocs, 0.)
+		is.Equal(c, res, val)
+		is.Equal(c, lifted, lowered)
+	}
+}
+
+func TestMemoryRoundtrip(t *testing.T) {
+	t.Run("Int8", testMemoryRoundtrip(wypes.Int8(-3)))
+	t.Run("Int16", testMemoryRoundtrip(wypes.Int16(-300)))
+	t.Run("Int32", testMemoryRoundtrip(wypes.Int32(-70000)))
+	t.Run("Int64", testMemoryRoundtrip(wypes.Int64(-1<<40)))
+	t.Run("UInt8", testMemoryRoundtrip(wypes.UInt8(200)))
+	t.Run("UInt16", testMemoryRoundtrip(wypes.UInt16(60000)))
+	t.Run("UInt32", testMemoryRoundtrip(wypes.UInt32(1<<31)))
+	t.Run("UInt64", testMemoryRoundtrip(wypes.UInt64(1<<63)))
+	t.Run("Float32", testMemoryRoundtrip(wypes.Float32(1.5)))
+	t.Run("Float64", testMemoryRoundtrip(wypes.Float64(1e100)))
+	t.Run("Bool", testMemoryRoundtrip(wypes.Bool(true)))
+}
+
 // A static check that all primitive types can be implicitly cast from literals.
 func TestAssignLiteral(t *testing.T) {
 	var _ wypes.Int8 = 123
@@ -197,3 +233,33 @@ func TestHostRef_Drop(t *testing.T) {
 	val2.Drop()
 	is.Equal(c, len(refs.Raw), 0)
 }
+
+func TestData_Lift(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	stack.Push(42)
+	store := wypes.Store{
+		Stack: stack,
+		Data:  &user{"aragorn"},
+	}
+	val := wypes.Data[*user]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, val.Unwrap().name, "aragorn")
+	// the stack is not touched
+	is.Equal(c, stack.Len(), 1)
+}
+
+func TestData_Lift_Mismatch(t *testing.T) {
+	c := is.NewRelaxed(t)
+	store := wypes.Store{Data: user{"aragorn"}}
+	val := wypes.Data[*user]{}.Lift(&store)
+	is.True(c, val.Unwrap() == nil)
+	is.True(c, errors.Is(store.Error, wypes.ErrDataType))
+	var liftErr *wypes.LiftError
+	is.True(c, errors.As(store.Error, &liftErr))
+	is.True(c, strings.Contains(store.Error.Error(), "got wypes_test.user"))
+
+	store = wypes.Store{}
+	wypes.Data[*user]{}.Lift(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrDataType))
+}
@@ -1,7 +1,5 @@
 package wypes
 
-import "encoding/binary"
-
 // UInt8 wraps uint8, 8-bit unsigned integer.
 type UInt8 uint8
 
@@ -28,27 +26,37 @@ func (v UInt8) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (UInt8) MemoryLift(s *Store, offset uint32) (UInt8, uint32) {
-	raw, ok := s.Memory.Read(offset, uInt8Size)
+func (v UInt8) MemoryLift(s *Store, offset uint32) (UInt8, uint32) {
+	raw, ok := s.Memory.ReadUint8(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uInt8Size)
 		return UInt8(0), 0
 	}
 
-	return UInt8(raw[0]), uInt8Size
+	return UInt8(raw), uInt8Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v UInt8) MemoryLower(s *Store, offset uint32) (length uint32) {
-	ok := s.Memory.Write(offset, []byte{byte(v)})
+	ok := s.Memory.WriteUint8(offset, byte(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uInt8Size)
 		return 0
 	}
 
 	return uInt8Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (UInt8) MemorySize() uint32 {
+	return uInt8Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (UInt8) MemoryAlign() uint32 {
+	return uInt8Size
+}
+
 // UInt16 wraps uint16, 16-bit unsigned integer.
 type UInt16 uint16
 
@@ -75,29 +83,37 @@ func (v UInt16) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (UInt16) MemoryLift(s *Store, offset uint32) (UInt16, uint32) {
-	raw, ok := s.Memory.Read(offset, uInt16Size)
+func (v UInt16) MemoryLift(s *Store, offset uint32) (UInt16, uint32) {
+	raw, ok := s.Memory.ReadUint16Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uInt16Size)
 		return UInt16(0), 0
 	}
 
-	return UInt16(binary.LittleEndian.Uint16(raw)), uInt16Size
+	return UInt16(raw), uInt16Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v UInt16) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, uInt16Size)
-	binary.LittleEndian.PutUint16(data, uint16(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint16Le(offset, uint16(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uInt16Size)
 		return 0
 	}
 
 	return uInt16Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (UInt16) MemorySize() uint32 {
+	return uInt16Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (UInt16) MemoryAlign() uint32 {
+	return uInt16Size
+}
+
 // UInt32 wraps uint32, 32-bit unsigned integer.
 type UInt32 uint32
 
@@ -124,29 +140,37 @@ func (v UInt32) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (UInt32) MemoryLift(s *Store, offset uint32) (UInt32, uint32) {
-	raw, ok := s.Memory.Read(offset, uInt32Size)
+func (v UInt32) MemoryLift(s *Store, offset uint32) (UInt32, uint32) {
+	raw, ok := s.Memory.ReadUint32Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uInt32Size)
 		return UInt32(0), 0
 	}
 
-	return UInt32(binary.LittleEndian.Uint32(raw)), uInt32Size
+	return UInt32(raw), uInt32Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v UInt32) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, uInt32Size)
-	binary.LittleEndian.PutUint32(data, uint32(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint32Le(offset, uint32(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uInt32Size)
 		return 0
 	}
 
 	return uInt32Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (UInt32) MemorySize() uint32 {
+	return uInt32Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (UInt32) MemoryAlign() uint32 {
+	return uInt32Size
+}
+
 // UInt64 wraps uint64, 64-bit unsigned integer.
 type UInt64 uint64
 
@@ -173,29 +197,37 @@ func (v UInt64) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (UInt64) MemoryLift(s *Store, offset uint32) (UInt64, uint32) {
-	raw, ok := s.Memory.Read(offset, uInt64Size)
+func (v UInt64) MemoryLift(s *Store, offset uint32) (UInt64, uint32) {
+	raw, ok := s.Memory.ReadUint64Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uInt64Size)
 		return UInt64(0), 0
 	}
 
-	return UInt64(binary.LittleEndian.Uint64(raw)), uInt64Size
+	return UInt64(raw), uInt64Size
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v UInt64) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, uInt64Size)
-	binary.LittleEndian.PutUint64(data, uint64(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint64Le(offset, uint64(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uInt64Size)
 		return 0
 	}
 
 	return uInt64Size
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (UInt64) MemorySize() uint32 {
+	return uInt64Size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (UInt64) MemoryAlign() uint32 {
+	return uInt64Size
+}
+
 // UInt wraps uint, 32-bit unsigned integer.
 type UInt uint
 
@@ -222,29 +254,37 @@ func (v UInt) Lower(s *Store) {
 }
 
 // MemoryLift implements [Reader] interface.
-func (UInt) MemoryLift(s *Store, offset uint32) (UInt, uint32) {
-	raw, ok := s.Memory.Read(offset, uIntSize)
+func (v UInt) MemoryLift(s *Store, offset uint32) (UInt, uint32) {
+	raw, ok := s.Memory.ReadUint64Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uIntSize)
 		return UInt(0), 0
 	}
 
-	return UInt(binary.LittleEndian.Uint64(raw)), uIntSize
+	return UInt(raw), uIntSize
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v UInt) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, uIntSize)
-	binary.LittleEndian.PutUint64(data, uint64(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint64Le(offset, uint64(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uIntSize)
 		return 0
 	}
 
 	return uIntSize
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (UInt) MemorySize() uint32 {
+	return uIntSize
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (UInt) MemoryAlign() uint32 {
+	return uIntSize
+}
+
 // UIntPtr wraps uintptr, pointer-sized unsigned integer.
 type UIntPtr uintptr
 
@@ -271,29 +311,37 @@ func (v UIntPtr) Lower(s *Store) {
 }
 
 // MemoryLift implements [MemoryLift] interface.
-func (UIntPtr) MemoryLift(s *Store, offset uint32) (UIntPtr, uint32) {
-	raw, ok := s.Memory.Read(offset, uIntPtrSize)
+func (v UIntPtr) MemoryLift(s *Store, offset uint32) (UIntPtr, uint32) {
+	raw, ok := s.Memory.ReadUint64Le(offset)
 	if !ok {
-		s.Error = ErrMemRead
+		s.liftFailed(ErrMemRead, v, offset, uIntPtrSize)
 		return UIntPtr(0), 0
 	}
 
-	return UIntPtr(binary.LittleEndian.Uint64(raw)), uIntPtrSize
+	return UIntPtr(raw), uIntPtrSize
 }
 
 // MemoryLower implements [MemoryLower] interface.
 func (v UIntPtr) MemoryLower(s *Store, offset uint32) (length uint32) {
-	data := make([]byte, uIntPtrSize)
-	binary.LittleEndian.PutUint64(data, uint64(v))
-	ok := s.Memory.Write(offset, data)
+	ok := s.Memory.WriteUint64Le(offset, uint64(v))
 	if !ok {
-		s.Error = ErrMemWrite
+		s.lowerFailed(ErrMemWrite, v, offset, uIntPtrSize)
 		return 0
 	}
 
 	return uIntPtrSize
 }
 
+// MemorySize implements [MemoryLayout] interface.
+func (UIntPtr) MemorySize() uint32 {
+	return uIntPtrSize
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (UIntPtr) MemoryAlign() uint32 {
+	return uIntPtrSize
+}
+
 // Rune is an alias for [UInt32].
 type Rune = UInt32
 
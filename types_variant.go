@@ -0,0 +1,1194 @@
+package wypes
+
+import "fmt"
+
+// EnumCases is implemented by types that describe the cases of an [Enum].
+type EnumCases interface {
+	// Cases returns the names of all the enum cases in order.
+	Cases() []string
+}
+
+// Enum wraps the discriminant of a component model enum.
+//
+// The type parameter describes the enum cases. For example:
+//
+//	type Color struct{}
+//
+//	func (Color) Cases() []string {
+//		return []string{"red", "green", "blue"}
+//	}
+//
+// Then Enum[Color] can be used as a host function argument or result.
+type Enum[C EnumCases] struct {
+	Case uint32
+}
+
+// Unwrap returns the wrapped value.
+func (v Enum[C]) Unwrap() uint32 {
+	return v.Case
+}
+
+// String returns the name of the enum case.
+func (v Enum[C]) String() string {
+	var c C
+	cases := c.Cases()
+	if v.Case >= uint32(len(cases)) {
+		return fmt.Sprintf("<invalid case %d>", v.Case)
+	}
+	return cases[v.Case]
+}
+
+// ValueTypes implements [Value] interface.
+func (Enum[C]) ValueTypes() []ValueType {
+	return []ValueType{ValueTypeI32}
+}
+
+// Lift implements [Lift] interface.
+func (v Enum[C]) Lift(s *Store) Enum[C] {
+	disc := uint32(s.Stack.Pop())
+	if !v.valid(disc) {
+		s.liftFailed(ErrDiscriminant, v, 0, 0)
+		return Enum[C]{}
+	}
+	return Enum[C]{Case: disc}
+}
+
+// Lower implements [Lower] interface.
+func (v Enum[C]) Lower(s *Store) {
+	if !v.valid(v.Case) {
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	s.Stack.Push(Raw(v.Case))
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Enum[C]) MemoryLift(s *Store, offset uint32) (Enum[C], uint32) {
+	size := v.MemorySize()
+	disc, ok := liftDiscriminant(s, v, offset, size)
+	if !ok {
+		return Enum[C]{}, size
+	}
+	if !v.valid(disc) {
+		s.liftFailed(ErrDiscriminant, v, offset, size)
+		return Enum[C]{}, size
+	}
+	return Enum[C]{Case: disc}, size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Enum[C]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	size := v.MemorySize()
+	if !v.valid(v.Case) {
+		s.lowerFailed(ErrDiscriminant, v, offset, size)
+		return size
+	}
+	lowerDiscriminant(s, v, offset, size, v.Case)
+	return size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (Enum[C]) MemorySize() uint32 {
+	var c C
+	return discriminantSize(len(c.Cases()))
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Enum[C]) MemoryAlign() uint32 {
+	return v.MemorySize()
+}
+
+func (Enum[C]) valid(disc uint32) bool {
+	var c C
+	return disc < uint32(len(c.Cases()))
+}
+
+// discriminantSize returns the size (and the alignment) in memory
+// of the discriminant of a variant with the given number of cases.
+func discriminantSize(cases int) uint32 {
+	switch {
+	case cases <= 1<<8:
+		return 1
+	case cases <= 1<<16:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// liftDiscriminant reads from memory a discriminant of the given size.
+func liftDiscriminant(s *Store, typ Value, offset, size uint32) (uint32, bool) {
+	disc, ok := readUintLe(s.Memory, offset, size)
+	if !ok {
+		s.liftFailed(ErrMemRead, typ, offset, size)
+		return 0, false
+	}
+	return uint32(disc), true
+}
+
+// lowerDiscriminant writes into memory a discriminant of the given size.
+func lowerDiscriminant(s *Store, typ Value, offset, size, disc uint32) bool {
+	ok := writeUintLe(s.Memory, offset, size, uint64(disc))
+	if !ok {
+		s.lowerFailed(ErrMemWrite, typ, offset, size)
+	}
+	return ok
+}
+
+// variantCase is a value of a variant case.
+type variantCase interface {
+	Value
+	MemoryLayout
+}
+
+// variantValueTypes returns the flattened value types of a variant:
+// the discriminant followed by the joined value types of all the cases.
+//
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/CanonicalABI.md#flattening
+func variantValueTypes(cases []variantCase) []ValueType {
+	joined := []ValueType{}
+	for _, c := range cases {
+		for i, t := range c.ValueTypes() {
+			if i == len(joined) {
+				joined = append(joined, t)
+			} else {
+				joined[i] = joinValueType(joined[i], t)
+			}
+		}
+	}
+	return append([]ValueType{ValueTypeI32}, joined...)
+}
+
+// joinValueType returns the value type that can hold values of both given types.
+//
+// Since the raw values on the stack are bit patterns, the values themselves
+// don't need to be converted, only the declared type of the stack slot changes.
+func joinValueType(a, b ValueType) ValueType {
+	if a == b {
+		return a
+	}
+	if (a == ValueTypeI32 && b == ValueTypeF32) || (a == ValueTypeF32 && b == ValueTypeI32) {
+		return ValueTypeI32
+	}
+	return ValueTypeI64
+}
+
+// liftVariant pops a flattened variant from the stack
+// and returns the discriminant and the raw values of the payload.
+func liftVariant(s *Store, typ Value, cases []variantCase) (uint32, []Raw) {
+	n := len(variantValueTypes(cases)) - 1
+	payload := popRaw(s, n)
+	disc := uint32(s.Stack.Pop())
+	if disc >= uint32(len(cases)) {
+		s.liftFailed(ErrDiscriminant, typ, 0, 0)
+	}
+	return disc, payload
+}
+
+// liftCase lifts the value of a variant case from the raw values of the payload.
+func liftCase[T Lift[T]](s *Store, v T, payload []Raw) T {
+	return liftRaw(s, v, payload[:len(v.ValueTypes())])
+}
+
+// padVariant pushes zeros after the lowered value of the variant case
+// to fill the stack slots reserved for the values of other cases.
+func padVariant(s *Store, cases []variantCase, disc uint32) {
+	n := len(variantValueTypes(cases)) - 1
+	if disc < uint32(len(cases)) {
+		n -= len(cases[disc].ValueTypes())
+	}
+	for i := 0; i < n; i++ {
+		s.Stack.Push(0)
+	}
+}
+
+// variantLayout describes how a variant is stored in memory.
+type variantLayout struct {
+	cases int
+
+	// disc is the size of the discriminant.
+	disc uint32
+
+	// payload is the offset of the case value relative to the start of the variant.
+	payload uint32
+
+	size  uint32
+	align uint32
+}
+
+func newVariantLayout(cases []variantCase) variantLayout {
+	disc := discriminantSize(len(cases))
+	maxSize := uint32(0)
+	maxAlign := disc
+	for _, c := range cases {
+		maxSize = max(maxSize, c.MemorySize())
+		maxAlign = max(maxAlign, c.MemoryAlign())
+	}
+	payload := alignTo(disc, maxAlign)
+	return variantLayout{
+		cases:   len(cases),
+		disc:    disc,
+		payload: payload,
+		size:    alignTo(payload+maxSize, maxAlign),
+		align:   maxAlign,
+	}
+}
+
+// lift reads and validates the discriminant of the variant.
+func (l variantLayout) lift(s *Store, typ Value, offset uint32) (uint32, bool) {
+	disc, ok := liftDiscriminant(s, typ, offset, l.disc)
+	if !ok {
+		return 0, false
+	}
+	if disc >= uint32(l.cases) {
+		s.liftFailed(ErrDiscriminant, typ, offset, l.disc)
+		return 0, false
+	}
+	return disc, true
+}
+
+// lower validates and writes the discriminant of the variant.
+func (l variantLayout) lower(s *Store, typ Value, offset, disc uint32) bool {
+	if disc >= uint32(l.cases) {
+		s.lowerFailed(ErrDiscriminant, typ, offset, l.disc)
+		return false
+	}
+	return lowerDiscriminant(s, typ, offset, l.disc, disc)
+}
+
+// Variant2 wraps a component model variant with 2 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant2[A FullLiftLower[A], B FullLiftLower[B]] struct {
+	Case uint32
+	A    A
+	B    B
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant2[A, B]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant2[A, B]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant2[A, B]) Lift(s *Store) Variant2[A, B] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant2[A, B]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant2[A, B]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant2[A, B]) MemoryLift(s *Store, offset uint32) (Variant2[A, B], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant2[A, B]{}, layout.size
+	}
+	res := Variant2[A, B]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant2[A, B]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant2[A, B]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant2[A, B]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant2[A, B]) cases() []variantCase {
+	return []variantCase{v.A, v.B}
+}
+
+// Variant3 wraps a component model variant with 3 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant3[A FullLiftLower[A], B FullLiftLower[B], C FullLiftLower[C]] struct {
+	Case uint32
+	A    A
+	B    B
+	C    C
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant3[A, B, C]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	case 2:
+		return v.C
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant3[A, B, C]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant3[A, B, C]) Lift(s *Store) Variant3[A, B, C] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant3[A, B, C]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	case 2:
+		res.C = liftCase(s, res.C, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant3[A, B, C]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	case 2:
+		v.C.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant3[A, B, C]) MemoryLift(s *Store, offset uint32) (Variant3[A, B, C], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant3[A, B, C]{}, layout.size
+	}
+	res := Variant3[A, B, C]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	case 2:
+		res.C, _ = res.C.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant3[A, B, C]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	case 2:
+		v.C.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant3[A, B, C]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant3[A, B, C]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant3[A, B, C]) cases() []variantCase {
+	return []variantCase{v.A, v.B, v.C}
+}
+
+// Variant4 wraps a component model variant with 4 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant4[A FullLiftLower[A], B FullLiftLower[B], C FullLiftLower[C], D FullLiftLower[D]] struct {
+	Case uint32
+	A    A
+	B    B
+	C    C
+	D    D
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant4[A, B, C, D]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	case 2:
+		return v.C
+	case 3:
+		return v.D
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant4[A, B, C, D]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant4[A, B, C, D]) Lift(s *Store) Variant4[A, B, C, D] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant4[A, B, C, D]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	case 2:
+		res.C = liftCase(s, res.C, payload)
+	case 3:
+		res.D = liftCase(s, res.D, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant4[A, B, C, D]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	case 2:
+		v.C.Lower(s)
+	case 3:
+		v.D.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant4[A, B, C, D]) MemoryLift(s *Store, offset uint32) (Variant4[A, B, C, D], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant4[A, B, C, D]{}, layout.size
+	}
+	res := Variant4[A, B, C, D]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	case 2:
+		res.C, _ = res.C.MemoryLift(s, payload)
+	case 3:
+		res.D, _ = res.D.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant4[A, B, C, D]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	case 2:
+		v.C.MemoryLower(s, payload)
+	case 3:
+		v.D.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant4[A, B, C, D]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant4[A, B, C, D]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant4[A, B, C, D]) cases() []variantCase {
+	return []variantCase{v.A, v.B, v.C, v.D}
+}
+
+// Variant5 wraps a component model variant with 5 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant5[A FullLiftLower[A], B FullLiftLower[B], C FullLiftLower[C], D FullLiftLower[D], E FullLiftLower[E]] struct {
+	Case uint32
+	A    A
+	B    B
+	C    C
+	D    D
+	E    E
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant5[A, B, C, D, E]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	case 2:
+		return v.C
+	case 3:
+		return v.D
+	case 4:
+		return v.E
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant5[A, B, C, D, E]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant5[A, B, C, D, E]) Lift(s *Store) Variant5[A, B, C, D, E] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant5[A, B, C, D, E]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	case 2:
+		res.C = liftCase(s, res.C, payload)
+	case 3:
+		res.D = liftCase(s, res.D, payload)
+	case 4:
+		res.E = liftCase(s, res.E, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant5[A, B, C, D, E]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	case 2:
+		v.C.Lower(s)
+	case 3:
+		v.D.Lower(s)
+	case 4:
+		v.E.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant5[A, B, C, D, E]) MemoryLift(s *Store, offset uint32) (Variant5[A, B, C, D, E], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant5[A, B, C, D, E]{}, layout.size
+	}
+	res := Variant5[A, B, C, D, E]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	case 2:
+		res.C, _ = res.C.MemoryLift(s, payload)
+	case 3:
+		res.D, _ = res.D.MemoryLift(s, payload)
+	case 4:
+		res.E, _ = res.E.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant5[A, B, C, D, E]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	case 2:
+		v.C.MemoryLower(s, payload)
+	case 3:
+		v.D.MemoryLower(s, payload)
+	case 4:
+		v.E.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant5[A, B, C, D, E]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant5[A, B, C, D, E]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant5[A, B, C, D, E]) cases() []variantCase {
+	return []variantCase{v.A, v.B, v.C, v.D, v.E}
+}
+
+// Variant6 wraps a component model variant with 6 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant6[A FullLiftLower[A], B FullLiftLower[B], C FullLiftLower[C], D FullLiftLower[D], E FullLiftLower[E], F FullLiftLower[F]] struct {
+	Case uint32
+	A    A
+	B    B
+	C    C
+	D    D
+	E    E
+	F    F
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant6[A, B, C, D, E, F]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	case 2:
+		return v.C
+	case 3:
+		return v.D
+	case 4:
+		return v.E
+	case 5:
+		return v.F
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant6[A, B, C, D, E, F]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant6[A, B, C, D, E, F]) Lift(s *Store) Variant6[A, B, C, D, E, F] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant6[A, B, C, D, E, F]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	case 2:
+		res.C = liftCase(s, res.C, payload)
+	case 3:
+		res.D = liftCase(s, res.D, payload)
+	case 4:
+		res.E = liftCase(s, res.E, payload)
+	case 5:
+		res.F = liftCase(s, res.F, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant6[A, B, C, D, E, F]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	case 2:
+		v.C.Lower(s)
+	case 3:
+		v.D.Lower(s)
+	case 4:
+		v.E.Lower(s)
+	case 5:
+		v.F.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant6[A, B, C, D, E, F]) MemoryLift(s *Store, offset uint32) (Variant6[A, B, C, D, E, F], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant6[A, B, C, D, E, F]{}, layout.size
+	}
+	res := Variant6[A, B, C, D, E, F]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	case 2:
+		res.C, _ = res.C.MemoryLift(s, payload)
+	case 3:
+		res.D, _ = res.D.MemoryLift(s, payload)
+	case 4:
+		res.E, _ = res.E.MemoryLift(s, payload)
+	case 5:
+		res.F, _ = res.F.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant6[A, B, C, D, E, F]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	case 2:
+		v.C.MemoryLower(s, payload)
+	case 3:
+		v.D.MemoryLower(s, payload)
+	case 4:
+		v.E.MemoryLower(s, payload)
+	case 5:
+		v.F.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant6[A, B, C, D, E, F]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant6[A, B, C, D, E, F]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant6[A, B, C, D, E, F]) cases() []variantCase {
+	return []variantCase{v.A, v.B, v.C, v.D, v.E, v.F}
+}
+
+// Variant7 wraps a component model variant with 7 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant7[A FullLiftLower[A], B FullLiftLower[B], C FullLiftLower[C], D FullLiftLower[D], E FullLiftLower[E], F FullLiftLower[F], G FullLiftLower[G]] struct {
+	Case uint32
+	A    A
+	B    B
+	C    C
+	D    D
+	E    E
+	F    F
+	G    G
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant7[A, B, C, D, E, F, G]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	case 2:
+		return v.C
+	case 3:
+		return v.D
+	case 4:
+		return v.E
+	case 5:
+		return v.F
+	case 6:
+		return v.G
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant7[A, B, C, D, E, F, G]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant7[A, B, C, D, E, F, G]) Lift(s *Store) Variant7[A, B, C, D, E, F, G] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant7[A, B, C, D, E, F, G]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	case 2:
+		res.C = liftCase(s, res.C, payload)
+	case 3:
+		res.D = liftCase(s, res.D, payload)
+	case 4:
+		res.E = liftCase(s, res.E, payload)
+	case 5:
+		res.F = liftCase(s, res.F, payload)
+	case 6:
+		res.G = liftCase(s, res.G, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant7[A, B, C, D, E, F, G]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	case 2:
+		v.C.Lower(s)
+	case 3:
+		v.D.Lower(s)
+	case 4:
+		v.E.Lower(s)
+	case 5:
+		v.F.Lower(s)
+	case 6:
+		v.G.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant7[A, B, C, D, E, F, G]) MemoryLift(s *Store, offset uint32) (Variant7[A, B, C, D, E, F, G], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant7[A, B, C, D, E, F, G]{}, layout.size
+	}
+	res := Variant7[A, B, C, D, E, F, G]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	case 2:
+		res.C, _ = res.C.MemoryLift(s, payload)
+	case 3:
+		res.D, _ = res.D.MemoryLift(s, payload)
+	case 4:
+		res.E, _ = res.E.MemoryLift(s, payload)
+	case 5:
+		res.F, _ = res.F.MemoryLift(s, payload)
+	case 6:
+		res.G, _ = res.G.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant7[A, B, C, D, E, F, G]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	case 2:
+		v.C.MemoryLower(s, payload)
+	case 3:
+		v.D.MemoryLower(s, payload)
+	case 4:
+		v.E.MemoryLower(s, payload)
+	case 5:
+		v.F.MemoryLower(s, payload)
+	case 6:
+		v.G.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant7[A, B, C, D, E, F, G]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant7[A, B, C, D, E, F, G]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant7[A, B, C, D, E, F, G]) cases() []variantCase {
+	return []variantCase{v.A, v.B, v.C, v.D, v.E, v.F, v.G}
+}
+
+// Variant8 wraps a component model variant with 8 cases.
+//
+// Case is the index of the active case, and the field with the same index
+// (A for 0, B for 1, and so on) holds its value. Use [Void] for cases without a value.
+type Variant8[A FullLiftLower[A], B FullLiftLower[B], C FullLiftLower[C], D FullLiftLower[D], E FullLiftLower[E], F FullLiftLower[F], G FullLiftLower[G], H FullLiftLower[H]] struct {
+	Case uint32
+	A    A
+	B    B
+	C    C
+	D    D
+	E    E
+	F    F
+	G    G
+	H    H
+}
+
+// Unwrap returns the value of the active case.
+func (v Variant8[A, B, C, D, E, F, G, H]) Unwrap() any {
+	switch v.Case {
+	case 0:
+		return v.A
+	case 1:
+		return v.B
+	case 2:
+		return v.C
+	case 3:
+		return v.D
+	case 4:
+		return v.E
+	case 5:
+		return v.F
+	case 6:
+		return v.G
+	case 7:
+		return v.H
+	}
+	return nil
+}
+
+// ValueTypes implements [Value] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) ValueTypes() []ValueType {
+	return variantValueTypes(v.cases())
+}
+
+// Lift implements [Lift] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) Lift(s *Store) Variant8[A, B, C, D, E, F, G, H] {
+	disc, payload := liftVariant(s, v, v.cases())
+	res := Variant8[A, B, C, D, E, F, G, H]{Case: disc}
+	switch disc {
+	case 0:
+		res.A = liftCase(s, res.A, payload)
+	case 1:
+		res.B = liftCase(s, res.B, payload)
+	case 2:
+		res.C = liftCase(s, res.C, payload)
+	case 3:
+		res.D = liftCase(s, res.D, payload)
+	case 4:
+		res.E = liftCase(s, res.E, payload)
+	case 5:
+		res.F = liftCase(s, res.F, payload)
+	case 6:
+		res.G = liftCase(s, res.G, payload)
+	case 7:
+		res.H = liftCase(s, res.H, payload)
+	}
+	return res
+}
+
+// Lower implements [Lower] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) Lower(s *Store) {
+	s.Stack.Push(Raw(v.Case))
+	switch v.Case {
+	case 0:
+		v.A.Lower(s)
+	case 1:
+		v.B.Lower(s)
+	case 2:
+		v.C.Lower(s)
+	case 3:
+		v.D.Lower(s)
+	case 4:
+		v.E.Lower(s)
+	case 5:
+		v.F.Lower(s)
+	case 6:
+		v.G.Lower(s)
+	case 7:
+		v.H.Lower(s)
+	default:
+		s.lowerFailed(ErrDiscriminant, v, 0, 0)
+	}
+	padVariant(s, v.cases(), v.Case)
+}
+
+// MemoryLift implements [MemoryLift] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) MemoryLift(s *Store, offset uint32) (Variant8[A, B, C, D, E, F, G, H], uint32) {
+	layout := newVariantLayout(v.cases())
+	disc, ok := layout.lift(s, v, offset)
+	if !ok {
+		return Variant8[A, B, C, D, E, F, G, H]{}, layout.size
+	}
+	res := Variant8[A, B, C, D, E, F, G, H]{Case: disc}
+	payload := offset + layout.payload
+	switch disc {
+	case 0:
+		res.A, _ = res.A.MemoryLift(s, payload)
+	case 1:
+		res.B, _ = res.B.MemoryLift(s, payload)
+	case 2:
+		res.C, _ = res.C.MemoryLift(s, payload)
+	case 3:
+		res.D, _ = res.D.MemoryLift(s, payload)
+	case 4:
+		res.E, _ = res.E.MemoryLift(s, payload)
+	case 5:
+		res.F, _ = res.F.MemoryLift(s, payload)
+	case 6:
+		res.G, _ = res.G.MemoryLift(s, payload)
+	case 7:
+		res.H, _ = res.H.MemoryLift(s, payload)
+	}
+	return res, layout.size
+}
+
+// MemoryLower implements [MemoryLower] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) MemoryLower(s *Store, offset uint32) (length uint32) {
+	layout := newVariantLayout(v.cases())
+	if !layout.lower(s, v, offset, v.Case) {
+		return layout.size
+	}
+	payload := offset + layout.payload
+	switch v.Case {
+	case 0:
+		v.A.MemoryLower(s, payload)
+	case 1:
+		v.B.MemoryLower(s, payload)
+	case 2:
+		v.C.MemoryLower(s, payload)
+	case 3:
+		v.D.MemoryLower(s, payload)
+	case 4:
+		v.E.MemoryLower(s, payload)
+	case 5:
+		v.F.MemoryLower(s, payload)
+	case 6:
+		v.G.MemoryLower(s, payload)
+	case 7:
+		v.H.MemoryLower(s, payload)
+	}
+	return layout.size
+}
+
+// MemorySize implements [MemoryLayout] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) MemorySize() uint32 {
+	return newVariantLayout(v.cases()).size
+}
+
+// MemoryAlign implements [MemoryLayout] interface.
+func (v Variant8[A, B, C, D, E, F, G, H]) MemoryAlign() uint32 {
+	return newVariantLayout(v.cases()).align
+}
+
+func (v Variant8[A, B, C, D, E, F, G, H]) cases() []variantCase {
+	return []variantCase{v.A, v.B, v.C, v.D, v.E, v.F, v.G, v.H}
+}
@@ -0,0 +1,127 @@
+package wypes_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+type color struct{}
+
+func (color) Cases() []string {
+	return []string{"red", "green", "blue"}
+}
+
+func TestEnum(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(64)}
+
+	wypes.Enum[color]{Case: 2}.Lower(&store)
+	val := wypes.Enum[color]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, val.Unwrap(), 2)
+	is.Equal(c, val.String(), "blue")
+
+	size := val.MemoryLower(&store, 8)
+	is.Equal(c, size, 1)
+	val, _ = wypes.Enum[color]{}.MemoryLift(&store, 8)
+	is.Equal(c, val.Unwrap(), 2)
+}
+
+func TestEnumInvalid(t *testing.T) {
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack}
+	stack.Push(3)
+	wypes.Enum[color]{}.Lift(&store)
+	is.True(c, errors.Is(store.Error, wypes.ErrDiscriminant))
+}
+
+func TestVariantValueTypes(t *testing.T) {
+	c := is.NewRelaxed(t)
+	i32 := wypes.ValueTypeI32
+	i64 := wypes.ValueTypeI64
+	f32 := wypes.ValueTypeF32
+	is.SliceEqual(c,
+		wypes.Variant2[wypes.Void, wypes.Void]{}.ValueTypes(),
+		[]wypes.ValueType{i32},
+	)
+	is.SliceEqual(c,
+		wypes.Variant2[wypes.Float32, wypes.Float32]{}.ValueTypes(),
+		[]wypes.ValueType{i32, f32},
+	)
+	is.SliceEqual(c,
+		wypes.Variant2[wypes.UInt32, wypes.Float32]{}.ValueTypes(),
+		[]wypes.ValueType{i32, i32},
+	)
+	is.SliceEqual(c,
+		wypes.Variant3[wypes.Float32, wypes.Int64, wypes.String]{}.ValueTypes(),
+		[]wypes.ValueType{i32, i64, i32},
+	)
+}
+
+func TestVariantStack(t *testing.T) {
+	type V = wypes.Variant3[wypes.Void, wypes.Float64, wypes.String]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(64)}
+
+	V{Case: 1, B: 3.5}.Lower(&store)
+	is.Equal(c, stack.Len(), 3)
+	val := V{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.Equal(c, val.Case, 1)
+	is.Equal(c, val.B, 3.5)
+
+	V{Case: 2, C: wypes.String{Offset: 8, Raw: "hi"}}.Lower(&store)
+	val = V{}.Lift(&store)
+	is.Equal(c, val.Case, 2)
+	is.Equal(c, val.C.Unwrap(), "hi")
+
+	V{Case: 0}.Lower(&store)
+	is.SliceEqual(c, *stack, []uint64{0, 0, 0})
+	val = V{}.Lift(&store)
+	is.Equal(c, val.Case, 0)
+	is.Err(is.Not(c), store.Error)
+}
+
+func TestVariantMemory(t *testing.T) {
+	type V = wypes.Variant2[wypes.UInt8, wypes.UInt64]
+	c := is.NewRelaxed(t)
+	memory := wypes.NewSliceMemory(64)
+	store := wypes.Store{Memory: memory}
+
+	size := V{Case: 1, B: 0x0102030405060708}.MemoryLower(&store, 16)
+	is.Equal(c, size, 16)
+	raw, _ := memory.Read(16, 16)
+	is.SliceEqual(c, raw, []byte{1, 0, 0, 0, 0, 0, 0, 0, 8, 7, 6, 5, 4, 3, 2, 1})
+
+	val, size := V{}.MemoryLift(&store, 16)
+	is.Equal(c, size, 16)
+	is.Equal(c, val, V{Case: 1, B: 0x0102030405060708})
+
+	ok := memory.Write(16, []byte{5})
+	is.True(c, ok)
+	V{}.MemoryLift(&store, 16)
+	is.True(c, errors.Is(store.Error, wypes.ErrDiscriminant))
+}
+
+func TestVariantList(t *testing.T) {
+	type V = wypes.Variant2[wypes.UInt16, wypes.Enum[color]]
+	c := is.NewRelaxed(t)
+	stack := wypes.NewSliceStack(4)
+	store := wypes.Store{Stack: stack, Memory: wypes.NewSliceMemory(1024)}
+
+	data := []V{
+		{Case: 0, A: 1000},
+		{Case: 1, B: wypes.Enum[color]{Case: 1}},
+		{Case: 0, A: 3},
+	}
+	wypes.List[V]{Offset: 64, Raw: data}.Lower(&store)
+	list := wypes.List[V]{}.Lift(&store)
+	is.Err(is.Not(c), store.Error)
+	is.SliceEqual(c, list.Unwrap(), data)
+}
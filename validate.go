@@ -0,0 +1,343 @@
+package wypes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ImportIssue is a problem with a single import found by [Modules.Validate].
+//
+// Use [errors.Is] to check the kind of the issue:
+// [ErrModuleMissing], [ErrFuncMissing], [ErrSignature], or [ErrFuncUnused].
+type ImportIssue struct {
+	// Module is the name of the imported module.
+	Module string
+
+	// Function is the name of the imported function.
+	Function string
+
+	// Host is the host function definition. It is nil if the function is missing.
+	Host *HostFunc
+
+	// GuestParams are the param types of the function as imported by the guest.
+	GuestParams []ValueType
+
+	// GuestResults are the result types of the function as imported by the guest.
+	GuestResults []ValueType
+
+	// Err is the kind of the issue.
+	Err error
+}
+
+// Error implements the error interface.
+func (i ImportIssue) Error() string {
+	msg := fmt.Sprintf("%s.%s: %v", i.Module, i.Function, i.Err)
+	if errors.Is(i.Err, ErrSignature) {
+		msg += fmt.Sprintf(
+			": host %s = %s, guest %s",
+			formatWypesSignature(i.Host.Params, i.Host.Results),
+			formatSignature(i.Host.ParamValueTypes(), i.Host.ResultValueTypes()),
+			formatSignature(i.GuestParams, i.GuestResults),
+		)
+	}
+	return msg
+}
+
+// Unwrap returns the kind of the issue.
+func (i ImportIssue) Unwrap() error {
+	return i.Err
+}
+
+// ImportReport is the result of [Modules.Validate].
+type ImportReport struct {
+	// Issues are all problems found.
+	//
+	// Issues with imports come in the order of the import section,
+	// followed by unused host functions sorted by module and function names.
+	Issues []ImportIssue
+}
+
+// Err returns an error if the guest imports missing functions
+// or functions with a different signature.
+//
+// Unused host functions are reported in [ImportReport.Issues] but aren't an error.
+func (r ImportReport) Err() error {
+	var errs []error
+	for _, issue := range r.Issues {
+		if !errors.Is(issue.Err, ErrFuncUnused) {
+			errs = append(errs, issue)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String returns all the issues, one per line.
+func (r ImportReport) String() string {
+	lines := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		lines[i] = issue.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the function imports of the given wasm binary against the host modules.
+//
+// It reports all imports of missing modules and functions, signature mismatches,
+// and host functions that the guest doesn't import. It doesn't need a runtime,
+// so it can be used before instantiation to get a readable report.
+// The error is returned only if the binary cannot be parsed.
+func (ms Modules) Validate(wasm []byte) (ImportReport, error) {
+	imports, err := parseFuncImports(wasm)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	var report ImportReport
+	imported := map[string]map[string]bool{}
+	for _, imp := range imports {
+		if imported[imp.module] == nil {
+			imported[imp.module] = map[string]bool{}
+		}
+		imported[imp.module][imp.name] = true
+		issue := ImportIssue{
+			Module:       imp.module,
+			Function:     imp.name,
+			GuestParams:  imp.params,
+			GuestResults: imp.results,
+		}
+		m, found := ms[imp.module]
+		if !found {
+			issue.Err = ErrModuleMissing
+			report.Issues = append(report.Issues, issue)
+			continue
+		}
+		hf, found := m[imp.name]
+		if !found {
+			issue.Err = ErrFuncMissing
+			report.Issues = append(report.Issues, issue)
+			continue
+		}
+		issue.Host = &hf
+		if !equalValueTypes(hf.ParamValueTypes(), imp.params) || !equalValueTypes(hf.ResultValueTypes(), imp.results) {
+			issue.Err = ErrSignature
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	for _, modName := range sortedKeys(ms) {
+		m := ms[modName]
+		for _, funcName := range sortedKeys(m) {
+			if !imported[modName][funcName] {
+				hf := m[funcName]
+				report.Issues = append(report.Issues, ImportIssue{
+					Module:   modName,
+					Function: funcName,
+					Host:     &hf,
+					Err:      ErrFuncUnused,
+				})
+			}
+		}
+	}
+	return report, nil
+}
+
+// formatWypesSignature formats function signature using wypes types,
+// like "(wypes.String) -> (wypes.UInt32)".
+func formatWypesSignature(params, results []Value) string {
+	format := func(values []Value) string {
+		names := make([]string, len(values))
+		for i, v := range values {
+			names[i] = fmt.Sprintf("%T", v)
+		}
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("(%s) -> (%s)", format(params), format(results))
+}
+
+// funcImport is a function imported by a wasm module.
+type funcImport struct {
+	module  string
+	name    string
+	params  []ValueType
+	results []ValueType
+}
+
+// parseFuncImports returns all the function imports of the given wasm binary.
+//
+// Only the type and import sections are parsed, everything else is skipped.
+func parseFuncImports(wasm []byte) ([]funcImport, error) {
+	r := wasmReader{data: wasm}
+	if len(wasm) < 8 || string(wasm[:4]) != "\x00asm" {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidWasm)
+	}
+	if string(wasm[4:8]) != "\x01\x00\x00\x00" {
+		return nil, fmt.Errorf("%w: unsupported version", ErrInvalidWasm)
+	}
+	r.pos = 8
+	var types [][2][]ValueType
+	var imports []funcImport
+	for !r.done() && r.err == nil {
+		id := r.byte()
+		size := r.u32()
+		end := r.pos + int(size)
+		if r.err != nil || end > len(wasm) {
+			return nil, fmt.Errorf("%w: section %d is out of bounds", ErrInvalidWasm, id)
+		}
+		switch id {
+		case 1:
+			types = r.types()
+		case 2:
+			imports = r.imports(types)
+		}
+		if r.err == nil && id <= 2 && r.pos != end {
+			r.err = fmt.Errorf("section %d size mismatch", id)
+		}
+		// all sections after the import section can be skipped
+		if id == 2 {
+			break
+		}
+		r.pos = end
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWasm, r.err)
+	}
+	return imports, nil
+}
+
+// wasmReader reads values from a wasm binary.
+//
+// The first error is recorded in err, all reads after that return zero values.
+type wasmReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *wasmReader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *wasmReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.done() {
+		r.err = errors.New("unexpected end of binary")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+// u64 reads an unsigned LEB128 integer.
+func (r *wasmReader) u64() uint64 {
+	var res uint64
+	for shift := 0; shift < 64; shift += 7 {
+		b := r.byte()
+		res |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return res
+		}
+	}
+	if r.err == nil {
+		r.err = errors.New("integer is too long")
+	}
+	return 0
+}
+
+func (r *wasmReader) u32() uint32 {
+	v := r.u64()
+	if v > 1<<32-1 && r.err == nil {
+		r.err = errors.New("integer is too large")
+	}
+	return uint32(v)
+}
+
+func (r *wasmReader) name() string {
+	size := int(r.u32())
+	if r.err != nil {
+		return ""
+	}
+	if r.pos+size > len(r.data) {
+		r.err = errors.New("name is out of bounds")
+		return ""
+	}
+	name := string(r.data[r.pos : r.pos+size])
+	r.pos += size
+	return name
+}
+
+func (r *wasmReader) valueTypes() []ValueType {
+	n := r.u32()
+	var types []ValueType
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		types = append(types, r.byte())
+	}
+	return types
+}
+
+// types reads the type section.
+func (r *wasmReader) types() [][2][]ValueType {
+	n := r.u32()
+	var types [][2][]ValueType
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		if form := r.byte(); form != 0x60 && r.err == nil {
+			r.err = fmt.Errorf("unsupported type form %#x", form)
+			return nil
+		}
+		params := r.valueTypes()
+		results := r.valueTypes()
+		types = append(types, [2][]ValueType{params, results})
+	}
+	return types
+}
+
+// imports reads the import section.
+func (r *wasmReader) imports(types [][2][]ValueType) []funcImport {
+	n := r.u32()
+	var imports []funcImport
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		modName := r.name()
+		funcName := r.name()
+		switch kind := r.byte(); kind {
+		case 0x00: // func
+			idx := r.u32()
+			if r.err == nil && int(idx) >= len(types) {
+				r.err = fmt.Errorf("type index %d is out of range", idx)
+				return nil
+			}
+			if r.err == nil {
+				imports = append(imports, funcImport{
+					module:  modName,
+					name:    funcName,
+					params:  types[idx][0],
+					results: types[idx][1],
+				})
+			}
+		case 0x01: // table
+			r.byte() // ref type
+			r.limits()
+		case 0x02: // memory
+			r.limits()
+		case 0x03: // global
+			r.byte() // value type
+			r.byte() // mutability
+		case 0x04: // tag
+			r.byte() // attribute
+			r.u32()  // type index
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("unknown import kind %#x", kind)
+			}
+		}
+	}
+	return imports
+}
+
+func (r *wasmReader) limits() {
+	flags := r.byte()
+	r.u64()
+	if flags&0x01 != 0 {
+		r.u64()
+	}
+}
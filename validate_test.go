@@ -0,0 +1,74 @@
+package wypes_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+)
+
+func TestValidate(t *testing.T) {
+	c := is.NewRelaxed(t)
+	i32 := wypes.ValueTypeI32
+	i64 := wypes.ValueTypeI64
+	modules := wypes.Modules{
+		"env": {
+			"add_i32": wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b }),
+			"print":   wypes.H1(func(s wypes.String) wypes.Void { return wypes.Void{} }),
+			"unused":  wypes.H0(func() wypes.Void { return wypes.Void{} }),
+		},
+	}
+	guest := buildWasm([]wasmImport{
+		{module: "env", name: "add_i32", params: []wypes.ValueType{i64, i64}, results: []wypes.ValueType{i64}},
+		{module: "env", name: "print", params: []wypes.ValueType{i32, i32}},
+		{module: "env", name: "missing"},
+		{module: "wasi", name: "exit", params: []wypes.ValueType{i32}},
+	}, nil)
+	report, err := modules.Validate(guest)
+	is.Err(is.Not(c), err)
+	is.Equal(c, len(report.Issues), 4)
+
+	is.Equal(c, report.Issues[0].Function, "add_i32")
+	is.True(c, errors.Is(report.Issues[0], wypes.ErrSignature))
+	msg := report.Issues[0].Error()
+	is.Equal(c, msg, "env.add_i32: function signature does not match: "+
+		"host (wypes.Int32, wypes.Int32) -> (wypes.Int32) = (i32, i32) -> (i32), "+
+		"guest (i64, i64) -> (i64)")
+
+	is.Equal(c, report.Issues[1].Function, "missing")
+	is.True(c, errors.Is(report.Issues[1], wypes.ErrFuncMissing))
+	is.Equal(c, report.Issues[2].Module, "wasi")
+	is.True(c, errors.Is(report.Issues[2], wypes.ErrModuleMissing))
+	is.Equal(c, report.Issues[3].Function, "unused")
+	is.True(c, errors.Is(report.Issues[3], wypes.ErrFuncUnused))
+
+	err = report.Err()
+	is.True(c, errors.Is(err, wypes.ErrSignature))
+	is.True(c, errors.Is(err, wypes.ErrModuleMissing))
+	is.True(c, !errors.Is(err, wypes.ErrFuncUnused))
+	is.True(c, strings.Contains(report.String(), "env.unused: host function is not imported"))
+}
+
+func TestValidate_OK(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H1(func(s wypes.String) wypes.UInt32 { return 0 })
+	modules := wypes.Modules{"env": {"f": f}}
+	guest := wasmGuest("env", "f", f.ParamValueTypes(), f.ResultValueTypes())
+	report, err := modules.Validate(guest)
+	is.Err(is.Not(c), err)
+	is.Equal(c, len(report.Issues), 0)
+	is.Err(is.Not(c), report.Err())
+}
+
+func TestValidate_InvalidWasm(t *testing.T) {
+	c := is.NewRelaxed(t)
+	modules := wypes.Modules{}
+	_, err := modules.Validate([]byte("nope"))
+	is.True(c, errors.Is(err, wypes.ErrInvalidWasm))
+
+	guest := wasmGuest("env", "f", nil, nil)
+	_, err = modules.Validate(guest[:20])
+	is.True(c, errors.Is(err, wypes.ErrInvalidWasm))
+}
@@ -0,0 +1,256 @@
+//go:build wasman
+// +build wasman
+
+package wypes
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/c0mm4nd/wasman"
+)
+
+// DefineWasman registers all the host modules in the given wasman linker.
+//
+// It is a shortcut for [Modules.Define] with [WasmanLinker].
+func (ms Modules) DefineWasman(linker *wasman.Linker, refs Refs, opts ...LinkOption) error {
+	return ms.Define(WasmanLinker{Linker: linker}, refs, opts...)
+}
+
+// WasmanLinker is a [Linker] that defines host modules in a wasman linker.
+//
+// Requires the "wasman" build tag.
+type WasmanLinker struct {
+	Linker *wasman.Linker
+}
+
+// DefineModule implements [Linker] interface.
+func (l WasmanLinker) DefineModule(modName string, funcs []LinkedFunc) error {
+	for _, f := range funcs {
+		f := f
+		err := l.Linker.DefineAdvancedFunc(modName, f.Name, func(ins *wasman.Instance) interface{} {
+			return wasmanAdaptFunc(ins, f)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wasmanAdaptFunc converts [LinkedFunc] into a Go function with typed params and results.
+//
+// Wasman infers the signature of host functions from their Go type,
+// so the function has to be constructed using reflection.
+func wasmanAdaptFunc(ins *wasman.Instance, f LinkedFunc) interface{} {
+	in := make([]reflect.Type, len(f.Params))
+	for i, t := range f.Params {
+		in[i] = wasmanGoType(t)
+	}
+	out := make([]reflect.Type, len(f.Results))
+	for i, t := range f.Results {
+		out[i] = wasmanGoType(t)
+	}
+	fnType := reflect.FuncOf(in, out, false)
+	fn := reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		stack := make([]uint64, max(len(in), len(out)))
+		for i, arg := range args {
+			stack[i] = wasmanToRaw(arg)
+		}
+		err := f.Call(context.Background(), wasmanMemory{ins}, wasmanAllocator{ins}, nil, stack)
+		if err != nil {
+			panic(err)
+		}
+		results := make([]reflect.Value, len(out))
+		for i, t := range out {
+			results[i] = wasmanFromRaw(t, stack[i])
+		}
+		return results
+	})
+	return fn.Interface()
+}
+
+func wasmanGoType(t ValueType) reflect.Type {
+	switch t {
+	case ValueTypeI64:
+		return reflect.TypeOf(int64(0))
+	case ValueTypeF32:
+		return reflect.TypeOf(float32(0))
+	case ValueTypeF64:
+		return reflect.TypeOf(float64(0))
+	default:
+		return reflect.TypeOf(int32(0))
+	}
+}
+
+func wasmanToRaw(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Int64:
+		return uint64(v.Int())
+	case reflect.Float32:
+		return uint64(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		return math.Float64bits(v.Float())
+	default:
+		return uint64(uint32(int32(v.Int())))
+	}
+}
+
+func wasmanFromRaw(t reflect.Type, raw uint64) reflect.Value {
+	switch t.Kind() {
+	case reflect.Int64:
+		return reflect.ValueOf(int64(raw))
+	case reflect.Float32:
+		return reflect.ValueOf(math.Float32frombits(uint32(raw)))
+	case reflect.Float64:
+		return reflect.ValueOf(math.Float64frombits(raw))
+	default:
+		return reflect.ValueOf(int32(uint32(raw)))
+	}
+}
+
+// wasmanMemory is a [Memory] of a wasman instance.
+type wasmanMemory struct {
+	ins *wasman.Instance
+}
+
+// Read implements [Memory] interface.
+func (m wasmanMemory) Read(offset Addr, count uint32) ([]byte, bool) {
+	if m.ins.Memory == nil {
+		return nil, false
+	}
+	data := m.ins.Memory.Value
+	end := uint64(offset) + uint64(count)
+	if end > uint64(len(data)) {
+		return nil, false
+	}
+	return data[offset:end], true
+}
+
+// Write implements [Memory] interface.
+func (m wasmanMemory) Write(offset Addr, v []byte) bool {
+	if m.ins.Memory == nil {
+		return false
+	}
+	data := m.ins.Memory.Value
+	end := uint64(offset) + uint64(len(v))
+	if end > uint64(len(data)) {
+		return false
+	}
+	copy(data[offset:end], v)
+	return true
+}
+
+// ReadUint8 implements [Memory] interface.
+func (m wasmanMemory) ReadUint8(offset Addr) (byte, bool) {
+	data, ok := m.Read(offset, 1)
+	if !ok {
+		return 0, false
+	}
+	return data[0], true
+}
+
+// ReadUint16Le implements [Memory] interface.
+func (m wasmanMemory) ReadUint16Le(offset Addr) (uint16, bool) {
+	data, ok := m.Read(offset, 2)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint16(data), true
+}
+
+// ReadUint32Le implements [Memory] interface.
+func (m wasmanMemory) ReadUint32Le(offset Addr) (uint32, bool) {
+	data, ok := m.Read(offset, 4)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(data), true
+}
+
+// ReadUint64Le implements [Memory] interface.
+func (m wasmanMemory) ReadUint64Le(offset Addr) (uint64, bool) {
+	data, ok := m.Read(offset, 8)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(data), true
+}
+
+// WriteUint8 implements [Memory] interface.
+func (m wasmanMemory) WriteUint8(offset Addr, v byte) bool {
+	data, ok := m.Read(offset, 1)
+	if !ok {
+		return false
+	}
+	data[0] = v
+	return true
+}
+
+// WriteUint16Le implements [Memory] interface.
+func (m wasmanMemory) WriteUint16Le(offset Addr, v uint16) bool {
+	data, ok := m.Read(offset, 2)
+	if !ok {
+		return false
+	}
+	binary.LittleEndian.PutUint16(data, v)
+	return true
+}
+
+// WriteUint32Le implements [Memory] interface.
+func (m wasmanMemory) WriteUint32Le(offset Addr, v uint32) bool {
+	data, ok := m.Read(offset, 4)
+	if !ok {
+		return false
+	}
+	binary.LittleEndian.PutUint32(data, v)
+	return true
+}
+
+// WriteUint64Le implements [Memory] interface.
+func (m wasmanMemory) WriteUint64Le(offset Addr, v uint64) bool {
+	data, ok := m.Read(offset, 8)
+	if !ok {
+		return false
+	}
+	binary.LittleEndian.PutUint64(data, v)
+	return true
+}
+
+// wasmanAllocator is an [Allocator] that calls the allocator exported by the guest module.
+//
+// Same as for wazero, cabi_realloc is used if available, with fallback to malloc.
+type wasmanAllocator struct {
+	ins *wasman.Instance
+}
+
+// Alloc implements [Allocator] interface.
+func (a wasmanAllocator) Alloc(ctx context.Context, size, align uint32) (Addr, error) {
+	var res []uint64
+	var err error
+	if a.exports("cabi_realloc") {
+		res, _, err = a.ins.CallExported("cabi_realloc", 0, 0, uint64(align), uint64(size))
+	} else if a.exports("malloc") {
+		res, _, err = a.ins.CallExported("malloc", uint64(size))
+	} else {
+		return 0, ErrNoAllocator
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrAlloc, err)
+	}
+	if len(res) != 1 || res[0] == 0 {
+		return 0, ErrAlloc
+	}
+	return Addr(res[0]), nil
+}
+
+func (a wasmanAllocator) exports(name string) bool {
+	if a.ins.Module == nil || a.ins.Module.ExportSection == nil {
+		return false
+	}
+	_, found := a.ins.Module.ExportSection[name]
+	return found
+}
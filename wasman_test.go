@@ -0,0 +1,54 @@
+//go:build wasman
+// +build wasman
+
+package wypes_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/c0mm4nd/wasman"
+	"github.com/c0mm4nd/wasman/config"
+	"github.com/orsinium-labs/wypes"
+)
+
+// wasmanRuntime is a [linkerRuntime] for the conformance suite.
+type wasmanRuntime struct {
+	lnk *wasman.Linker
+}
+
+func (r wasmanRuntime) linker() wypes.Linker {
+	return wypes.WasmanLinker{Linker: r.lnk}
+}
+
+func (r wasmanRuntime) instantiate(guest []byte) (linkerInstance, error) {
+	mod, err := wasman.NewModule(config.ModuleConfig{}, bytes.NewReader(guest))
+	if err != nil {
+		return nil, err
+	}
+	ins, err := r.lnk.Instantiate(mod)
+	return wasmanInstance{ins}, err
+}
+
+type wasmanInstance struct {
+	ins *wasman.Instance
+}
+
+func (i wasmanInstance) call(name string, params ...uint64) ([]uint64, error) {
+	res, _, err := i.ins.CallExported(name, params...)
+	return res, err
+}
+
+func (i wasmanInstance) read(offset, size uint32) []byte {
+	return i.ins.Memory.Value[offset : offset+size]
+}
+
+func (i wasmanInstance) write(offset uint32, data []byte) {
+	copy(i.ins.Memory.Value[offset:], data)
+}
+
+func TestWasman_Linker(t *testing.T) {
+	testLinker(t, func(t *testing.T) linkerRuntime {
+		return wasmanRuntime{wasman.NewLinker(config.LinkerConfig{})}
+	})
+}
@@ -5,51 +5,156 @@ package wypes
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
 )
 
 // DefineWazero registers all the host modules in the given wazero runtime.
-func (ms Modules) DefineWazero(runtime wazero.Runtime, refs Refs) error {
-	if refs == nil {
-		refs = NewMapRefs()
-	}
-	for modName, funcs := range ms {
-		err := funcs.DefineWazero(runtime, modName, refs)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+//
+// It is a shortcut for [Modules.Define] with [WazeroLinker].
+func (ms Modules) DefineWazero(runtime wazero.Runtime, refs Refs, opts ...LinkOption) error {
+	return ms.Define(WazeroLinker{Runtime: runtime}, refs, opts...)
 }
 
 // DefineWazero registers the host module in the given wazero runtime.
-func (m Module) DefineWazero(runtime wazero.Runtime, modName string, refs Refs) error {
-	var err error
-	mb := runtime.NewHostModuleBuilder(modName)
-	for funcName, funcDef := range m {
+//
+// It is a shortcut for [Module.Define] with [WazeroLinker].
+func (m Module) DefineWazero(runtime wazero.Runtime, modName string, refs Refs, opts ...LinkOption) error {
+	return m.Define(WazeroLinker{Runtime: runtime}, modName, refs, opts...)
+}
+
+// WazeroLinker is a [Linker] that defines host modules in a wazero runtime.
+type WazeroLinker struct {
+	Runtime wazero.Runtime
+
+	// Instances, if not nil, provides separate state for each guest module instance.
+	// The guests must then be instantiated with [WazeroInstances.Instantiate].
+	//
+	// With [WithCancellation], host functions also observe guest modules being closed.
+	Instances *WazeroInstances
+}
+
+// DefineModule implements [Linker] interface.
+func (l WazeroLinker) DefineModule(modName string, funcs []LinkedFunc) error {
+	mb := l.Runtime.NewHostModuleBuilder(modName)
+	for _, f := range funcs {
 		fb := mb.NewFunctionBuilder()
-		fb = fb.WithGoModuleFunction(
-			wazeroAdaptHostFunc(funcDef, refs),
-			funcDef.ParamValueTypes(),
-			funcDef.ResultValueTypes(),
-		)
-		mb = fb.Export(funcName)
+		fb = fb.WithGoModuleFunction(wazeroAdaptFunc(f, l.Instances), f.Params, f.Results)
+		mb = fb.Export(f.Name)
 	}
-	_, err = mb.Instantiate(context.Background())
+	_, err := mb.Instantiate(context.Background())
 	return err
 }
 
-func wazeroAdaptHostFunc(hf HostFunc, refs Refs) api.GoModuleFunction {
+func wazeroAdaptFunc(f LinkedFunc, instances *WazeroInstances) api.GoModuleFunction {
 	return api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
-		adaptedStack := SliceStack(stack)
-		store := Store{
-			Memory:  mod.Memory(),
-			Stack:   &adaptedStack,
-			Refs:    refs,
-			Context: ctx,
+		if f.Cancelable && instances != nil {
+			if closed := instances.closeContext(mod); closed != nil {
+				var stop func()
+				ctx, stop = wazeroWatchClose(ctx, closed)
+				defer stop()
+			}
+		}
+		var inst *Instance
+		if instances != nil {
+			var err error
+			inst, err = instances.Get(ctx, mod)
+			if err != nil {
+				panic(err)
+			}
+		}
+		// The memory and the allocator are taken from a pool
+		// because converting them into interfaces allocates.
+		env := wazeroEnvPool.Get().(*wazeroEnv)
+		env.alloc.mod = mod
+		var mem Memory
+		if m := mod.Memory(); m != nil {
+			env.mem.Memory = m
+			mem = &env.mem
+		}
+		err := f.Call(ctx, mem, &env.alloc, inst, stack)
+		*env = wazeroEnv{}
+		wazeroEnvPool.Put(env)
+		if err != nil {
+			// wazero recovers the panic and returns the error
+			// from the exported function called by the host.
+			panic(err)
 		}
-		hf.Call(&store)
 	})
 }
+
+// wazeroWatchClose returns a context that is canceled with the cause of closed
+// when closed is done. Call stop to release the resources.
+func wazeroWatchClose(ctx, closed context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	unregister := context.AfterFunc(closed, func() {
+		cancel(context.Cause(closed))
+	})
+	return ctx, func() {
+		unregister()
+		cancel(nil)
+	}
+}
+
+// wazeroEnv is the memory and the allocator of a wazero module passed into [LinkedFunc].
+type wazeroEnv struct {
+	mem   WazeroMemory
+	alloc wazeroAllocator
+}
+
+var wazeroEnvPool = sync.Pool{New: func() any { return new(wazeroEnv) }}
+
+// WazeroMemory adapts wazero memory to the [Memory] interface.
+type WazeroMemory struct {
+	api.Memory
+}
+
+// ReadUint8 implements [Memory] interface.
+func (m *WazeroMemory) ReadUint8(offset Addr) (byte, bool) {
+	return m.Memory.ReadByte(offset)
+}
+
+// WriteUint8 implements [Memory] interface.
+func (m *WazeroMemory) WriteUint8(offset Addr, v byte) bool {
+	return m.Memory.WriteByte(offset, v)
+}
+
+// wazeroMemoryOf returns the memory of the module or nil if it has no memory.
+func wazeroMemoryOf(mod api.Module) Memory {
+	m := mod.Memory()
+	if m == nil {
+		return nil
+	}
+	return &WazeroMemory{Memory: m}
+}
+
+// wazeroAllocator is an [Allocator] that calls the allocator exported by the guest module.
+//
+// It uses cabi_realloc from the component model canonical ABI if available,
+// and falls back to malloc.
+type wazeroAllocator struct {
+	mod api.Module
+}
+
+// Alloc implements [Allocator] interface.
+func (a wazeroAllocator) Alloc(ctx context.Context, size, align uint32) (Addr, error) {
+	var res []uint64
+	var err error
+	if fn := a.mod.ExportedFunction("cabi_realloc"); fn != nil {
+		res, err = fn.Call(ctx, 0, 0, uint64(align), uint64(size))
+	} else if fn := a.mod.ExportedFunction("malloc"); fn != nil {
+		res, err = fn.Call(ctx, uint64(size))
+	} else {
+		return 0, ErrNoAllocator
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrAlloc, err)
+	}
+	if len(res) != 1 || res[0] == 0 {
+		return 0, ErrAlloc
+	}
+	return Addr(res[0]), nil
+}
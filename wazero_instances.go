@@ -0,0 +1,265 @@
+//go:build !nowazero
+// +build !nowazero
+
+package wypes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// InstanceFactory creates the state for a guest module instance.
+//
+// It is called on the first host function call from the instance.
+// If it fails, the guest is trapped with the error.
+type InstanceFactory func(ctx context.Context, mod api.Module) (*Instance, error)
+
+// WazeroInstances keeps the state of every guest module instance in a wazero runtime.
+//
+// Pass it into [WazeroLinker] to give each guest instance its own [Refs] and [Store.Data]
+// instead of sharing them between all guests of the runtime.
+// The state is created lazily by the [InstanceFactory].
+//
+// The guests must be instantiated with [WazeroInstances.Instantiate], so that the state
+// is released when the guest module is closed. Host functions called by guests
+// instantiated in another way fail with [ErrNotInstantiated].
+// If [Instance.Data] implements [io.Closer], it is closed on release.
+//
+// Must be constructed with [NewWazeroInstances]. It is safe for concurrent use.
+type WazeroInstances struct {
+	factory InstanceFactory
+	mu      sync.Mutex
+	states  map[api.Module]*Instance
+
+	// notifiers are the close notifiers of the instances created by Instantiate.
+	notifiers map[api.Module]*instanceCloseNotifier
+}
+
+// NewWazeroInstances creates [WazeroInstances] with the given [InstanceFactory].
+//
+// If factory is nil, each instance gets a new [MapRefs] and no data.
+func NewWazeroInstances(factory InstanceFactory) *WazeroInstances {
+	if factory == nil {
+		factory = func(context.Context, api.Module) (*Instance, error) {
+			return &Instance{Refs: NewMapRefs()}, nil
+		}
+	}
+	return &WazeroInstances{
+		factory:   factory,
+		states:    make(map[api.Module]*Instance),
+		notifiers: make(map[api.Module]*instanceCloseNotifier),
+	}
+}
+
+// Instantiate instantiates the compiled guest module in the runtime
+// and makes sure that its state is released when the module is closed.
+// With [WithCancellation], it also lets host functions called by the module
+// observe the module being closed during the call.
+//
+// It is the same as [wazero.Runtime.InstantiateModule] but uses
+// [experimental.WithCloseNotifier] to get notified when the module is closed.
+//
+// [experimental.WithCloseNotifier]: https://pkg.go.dev/github.com/tetratelabs/wazero/experimental#WithCloseNotifier
+func (w *WazeroInstances) Instantiate(
+	ctx context.Context,
+	r wazero.Runtime,
+	compiled wazero.CompiledModule,
+	config wazero.ModuleConfig,
+) (api.Module, error) {
+	n := newInstanceCloseNotifier(w)
+	ctx = experimental.WithCloseNotifier(ctx, n)
+	// The start function may call host functions before the module is returned.
+	ctx = context.WithValue(ctx, instantiatingKey{w}, n)
+	mod, err := r.InstantiateModule(ctx, compiled, config)
+	if err != nil {
+		// The start function could call host functions before failing,
+		// and the failed module is not returned, so look for it.
+		w.releaseClosed()
+		return nil, err
+	}
+	n.mu.Lock()
+	n.mod = mod
+	closed := n.closed
+	n.mu.Unlock()
+	if closed {
+		w.Release(mod)
+		return mod, nil
+	}
+	w.mu.Lock()
+	w.notifiers[mod] = n
+	w.mu.Unlock()
+	// The module could be closed before the notifier is registered.
+	if mod.IsClosed() {
+		w.Release(mod)
+	}
+	return mod, nil
+}
+
+// instantiatingKey is the context key for the close notifier of the guest module
+// being instantiated by [WazeroInstances.Instantiate].
+type instantiatingKey struct {
+	instances *WazeroInstances
+}
+
+// instanceCloseNotifier releases the state of a guest module instance when it is closed.
+type instanceCloseNotifier struct {
+	instances *WazeroInstances
+
+	// done is canceled with [ErrModuleClosed] when the module is closed.
+	done   context.Context
+	cancel context.CancelCauseFunc
+
+	mu sync.Mutex
+	// mod is the guest module instance, set after the instantiation.
+	mod api.Module
+	// closed is set if the module is closed, even if before mod is set.
+	closed bool
+}
+
+func newInstanceCloseNotifier(w *WazeroInstances) *instanceCloseNotifier {
+	done, cancel := context.WithCancelCause(context.Background())
+	return &instanceCloseNotifier{instances: w, done: done, cancel: cancel}
+}
+
+// CloseNotify implements [experimental.CloseNotifier] interface.
+//
+// [experimental.CloseNotifier]: https://pkg.go.dev/github.com/tetratelabs/wazero/experimental#CloseNotifier
+func (n *instanceCloseNotifier) CloseNotify(ctx context.Context, exitCode uint32) {
+	n.cancel(ErrModuleClosed)
+	n.mu.Lock()
+	n.closed = true
+	mod := n.mod
+	n.mu.Unlock()
+	if mod != nil {
+		n.instances.Release(mod)
+	}
+}
+
+// Get returns the state of the given guest module instance, creating it if needed.
+//
+// It fails with [ErrNotInstantiated] if the instance is not instantiated
+// with [WazeroInstances.Instantiate], because its state would never be released.
+func (w *WazeroInstances) Get(ctx context.Context, mod api.Module) (*Instance, error) {
+	w.mu.Lock()
+	inst, found := w.states[mod]
+	_, registered := w.notifiers[mod]
+	w.mu.Unlock()
+	if found {
+		return inst, nil
+	}
+	if !registered && !w.registerInstantiating(ctx, mod) {
+		return nil, fmt.Errorf("%w: %s", ErrNotInstantiated, mod.Name())
+	}
+	// The lock isn't held while calling the factory
+	// because it may call the guest which in turn may call host functions.
+	inst, err := w.factory(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil {
+		inst = &Instance{}
+	}
+	w.mu.Lock()
+	existing, found := w.states[mod]
+	if !found {
+		w.states[mod] = inst
+	}
+	w.mu.Unlock()
+	if found {
+		releaseInstance(inst)
+		return existing, nil
+	}
+	return inst, nil
+}
+
+// registerInstantiating registers the close notifier of the given guest module instance
+// if it is being instantiated by [WazeroInstances.Instantiate] and calls host functions
+// from its start function.
+func (w *WazeroInstances) registerInstantiating(ctx context.Context, mod api.Module) bool {
+	n, ok := ctx.Value(instantiatingKey{w}).(*instanceCloseNotifier)
+	if !ok {
+		return false
+	}
+	n.mu.Lock()
+	n.mod = mod
+	n.mu.Unlock()
+	w.mu.Lock()
+	w.notifiers[mod] = n
+	w.mu.Unlock()
+	return true
+}
+
+// Len returns the number of guest module instances that have the state.
+func (w *WazeroInstances) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.states)
+}
+
+// Release releases the state of the given guest module instance.
+//
+// It is safe to call for an instance that has no state.
+func (w *WazeroInstances) Release(mod api.Module) {
+	w.mu.Lock()
+	inst, found := w.states[mod]
+	delete(w.states, mod)
+	// The notifier is still needed if the state is released before the module is closed.
+	if mod.IsClosed() {
+		delete(w.notifiers, mod)
+	}
+	w.mu.Unlock()
+	if found {
+		releaseInstance(inst)
+	}
+}
+
+// closeContext returns a context that is canceled with [ErrModuleClosed]
+// when the given guest module instance is closed.
+//
+// It returns nil if the instance is not instantiated with [WazeroInstances.Instantiate].
+func (w *WazeroInstances) closeContext(mod api.Module) context.Context {
+	w.mu.Lock()
+	n, found := w.notifiers[mod]
+	w.mu.Unlock()
+	if !found {
+		return nil
+	}
+	return n.done
+}
+
+// releaseClosed releases the state of all closed guest module instances.
+func (w *WazeroInstances) releaseClosed() {
+	var closed []*Instance
+	w.mu.Lock()
+	for mod, inst := range w.states {
+		if mod.IsClosed() {
+			delete(w.states, mod)
+			closed = append(closed, inst)
+		}
+	}
+	for mod := range w.notifiers {
+		if mod.IsClosed() {
+			delete(w.notifiers, mod)
+		}
+	}
+	w.mu.Unlock()
+	for _, inst := range closed {
+		releaseInstance(inst)
+	}
+}
+
+// releaseInstance closes [Instance.Data] if it is an [io.Closer].
+//
+// Must be called without the lock held because Close may take long
+// or call back into [WazeroInstances].
+func releaseInstance(inst *Instance) {
+	if closer, ok := inst.Data.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
@@ -0,0 +1,317 @@
+//go:build !nowazero
+// +build !nowazero
+
+package wypes_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// instanceData is the user state of a guest instance that counts calls.
+type instanceData struct {
+	calls  int32
+	closed bool
+}
+
+func (d *instanceData) Close() error {
+	d.closed = true
+	return nil
+}
+
+// instantiateN defines the host function as env.f using the given instances
+// and instantiates with them n guests that export "run" calling it.
+func instantiateN(
+	t *testing.T, ctx context.Context, hf wypes.HostFunc, insts *wypes.WazeroInstances, n int, opts ...wypes.LinkOption,
+) []api.Module {
+	r := wazero.NewRuntime(context.Background())
+	t.Cleanup(func() { r.Close(context.Background()) })
+	linker := wypes.WazeroLinker{Runtime: r, Instances: insts}
+	err := wypes.Modules{"env": {"f": hf}}.Define(linker, nil, opts...)
+	if err != nil {
+		t.Fatalf("define host functions: %v", err)
+	}
+	guest := wasmGuest("env", "f", hf.ParamValueTypes(), hf.ResultValueTypes())
+	compiled, err := r.CompileModule(ctx, guest)
+	if err != nil {
+		t.Fatalf("compile guest: %v", err)
+	}
+	mods := make([]api.Module, n)
+	for i := range mods {
+		config := wazero.NewModuleConfig().WithName(string(rune('a' + i)))
+		mods[i], err = insts.Instantiate(ctx, r, compiled, config)
+		if err != nil {
+			t.Fatalf("instantiate guest: %v", err)
+		}
+	}
+	return mods
+}
+
+func TestWazeroInstances_Refs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ctx := context.Background()
+	f := wypes.H1(func(s *wypes.Store) wypes.UInt32 {
+		return wypes.UInt32(s.Refs.Put("hi"))
+	})
+	mods := instantiateN(t, ctx, f, wypes.NewWazeroInstances(nil), 2)
+	res1, err := mods[0].ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	res2, err := mods[1].ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	// each guest has its own refs, so they get the same index
+	is.SliceEqual(c, res1, res2)
+	res3, err := mods[1].ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	is.True(c, res3[0] != res2[0])
+}
+
+func TestWazeroInstances_Data(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var created []*instanceData
+	insts := wypes.NewWazeroInstances(func(ctx context.Context, mod api.Module) (*wypes.Instance, error) {
+		data := &instanceData{}
+		created = append(created, data)
+		return &wypes.Instance{Data: data}, nil
+	})
+	f := wypes.H1(func(s *wypes.Store) wypes.Int32 {
+		data := s.Data.(*instanceData)
+		data.calls++
+		return wypes.Int32(data.calls)
+	})
+	ctx := context.Background()
+	mods := instantiateN(t, ctx, f, insts, 2)
+	is.Equal(c, insts.Len(), 0)
+
+	for i := 0; i < 3; i++ {
+		_, err := mods[0].ExportedFunction("run").Call(ctx)
+		is.Err(is.Not(c), err)
+	}
+	res, err := mods[1].ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{1})
+	is.Equal(c, len(created), 2)
+	is.Equal(c, created[0].calls, 3)
+	is.Equal(c, insts.Len(), 2)
+
+	// the state is released when the guest is closed
+	err = mods[0].Close(ctx)
+	is.Err(is.Not(c), err)
+	is.True(c, created[0].closed)
+	is.True(c, !created[1].closed)
+	is.Equal(c, insts.Len(), 1)
+
+	insts.Release(mods[1])
+	is.True(c, created[1].closed)
+	is.Equal(c, insts.Len(), 0)
+}
+
+func TestWazeroInstances_Release(t *testing.T) {
+	c := is.NewRelaxed(t)
+	insts := wypes.NewWazeroInstances(nil)
+	f := wypes.H0(func() wypes.Void { return wypes.Void{} })
+	ctx := context.Background()
+	mods := instantiateN(t, ctx, f, insts, 1)
+	_, err := mods[0].ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	is.Equal(c, insts.Len(), 1)
+	// the state can be released before the guest is closed
+	insts.Release(mods[0])
+	is.Equal(c, insts.Len(), 0)
+	_, err = mods[0].ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	is.Equal(c, insts.Len(), 1)
+	err = mods[0].Close(ctx)
+	is.Err(is.Not(c), err)
+	is.Equal(c, insts.Len(), 0)
+}
+
+func TestWazeroInstances_NotInstantiated(t *testing.T) {
+	c := is.NewRelaxed(t)
+	insts := wypes.NewWazeroInstances(nil)
+	called := false
+	f := wypes.H0(func() wypes.Void {
+		called = true
+		return wypes.Void{}
+	})
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+	err := wypes.Modules{"env": {"f": f}}.Define(wypes.WazeroLinker{Runtime: r, Instances: insts}, nil)
+	is.Err(is.Not(c), err)
+	// the guest is instantiated without WazeroInstances.Instantiate,
+	// so its state would never be released
+	mod, err := r.Instantiate(ctx, wasmGuest("env", "f", nil, nil))
+	is.Err(is.Not(c), err)
+	_, err = mod.ExportedFunction("run").Call(ctx)
+	is.True(c, errors.Is(err, wypes.ErrNotInstantiated))
+	is.True(c, !called)
+	is.Equal(c, insts.Len(), 0)
+}
+
+func TestWazeroInstances_StartFunction(t *testing.T) {
+	c := is.NewRelaxed(t)
+	insts := wypes.NewWazeroInstances(nil)
+	calls := 0
+	f := wypes.H0(func() wypes.Void {
+		calls++
+		return wypes.Void{}
+	})
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+	err := wypes.Modules{"env": {"f": f}}.Define(wypes.WazeroLinker{Runtime: r, Instances: insts}, nil)
+	is.Err(is.Not(c), err)
+	// "run" is the function 1, right after the import
+	guest := wasmWithStart(wasmGuest("env", "f", nil, nil), 1)
+	compiled, err := r.CompileModule(ctx, guest)
+	is.Err(is.Not(c), err)
+	mod, err := insts.Instantiate(ctx, r, compiled, wazero.NewModuleConfig())
+	is.Err(is.Not(c), err)
+	is.Equal(c, calls, 1)
+	is.Equal(c, insts.Len(), 1)
+	err = mod.Close(ctx)
+	is.Err(is.Not(c), err)
+	is.Equal(c, insts.Len(), 0)
+}
+
+// wasmWithStart adds the start section calling the function with the given index
+// into the wasm binary built by buildWasm.
+func wasmWithStart(bin []byte, fn uint32) []byte {
+	res := append([]byte{}, bin[:8]...)
+	for i := 8; i < len(bin); {
+		id := bin[i]
+		size, n := uint32(0), 0
+		for shift := 0; ; shift += 7 {
+			b := bin[i+1+n]
+			n++
+			size |= uint32(b&0x7f) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		end := i + 1 + n + int(size)
+		if id == 10 {
+			res = append(res, wasmSection(8, wasmLEB(fn))...)
+		}
+		res = append(res, bin[i:end]...)
+		i = end
+	}
+	return res
+}
+
+func TestWazeroInstances_RuntimeClose(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var released int
+	var insts *wypes.WazeroInstances
+	insts = wypes.NewWazeroInstances(func(ctx context.Context, mod api.Module) (*wypes.Instance, error) {
+		// Close calls back into WazeroInstances, so it must not be called under the lock
+		return &wypes.Instance{Data: closerFunc(func() error {
+			released++
+			insts.Len()
+			return nil
+		})}, nil
+	})
+	f := wypes.H0(func() wypes.Void { return wypes.Void{} })
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	err := wypes.Modules{"env": {"f": f}}.Define(wypes.WazeroLinker{Runtime: r, Instances: insts}, nil)
+	is.Err(is.Not(c), err)
+	compiled, err := r.CompileModule(ctx, wasmGuest("env", "f", nil, nil))
+	is.Err(is.Not(c), err)
+	for _, name := range []string{"a", "b"} {
+		mod, err := insts.Instantiate(ctx, r, compiled, wazero.NewModuleConfig().WithName(name))
+		is.Err(is.Not(c), err)
+		_, err = mod.ExportedFunction("run").Call(ctx)
+		is.Err(is.Not(c), err)
+	}
+	is.Equal(c, insts.Len(), 2)
+	// closing the runtime closes all guests
+	err = r.Close(ctx)
+	is.Err(is.Not(c), err)
+	is.Equal(c, insts.Len(), 0)
+	is.Equal(c, released, 2)
+}
+
+// closerFunc is an [io.Closer] calling the function.
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func TestWazeroInstances_FactoryError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	errFactory := errors.New("no tenant")
+	insts := wypes.NewWazeroInstances(func(ctx context.Context, mod api.Module) (*wypes.Instance, error) {
+		return nil, errFactory
+	})
+	called := false
+	f := wypes.H0(func() wypes.Void {
+		called = true
+		return wypes.Void{}
+	})
+	ctx := context.Background()
+	mods := instantiateN(t, ctx, f, insts, 1)
+	_, err := mods[0].ExportedFunction("run").Call(ctx)
+	is.True(c, errors.Is(err, errFactory))
+	is.True(c, !called)
+	is.Equal(c, insts.Len(), 0)
+}
+
+func TestWazeroInstances_DataParam(t *testing.T) {
+	c := is.NewRelaxed(t)
+	insts := wypes.NewWazeroInstances(func(ctx context.Context, mod api.Module) (*wypes.Instance, error) {
+		return &wypes.Instance{Data: &instanceData{calls: 10}}, nil
+	})
+	f := wypes.H2(func(d wypes.Data[*instanceData], x wypes.Int32) wypes.Int32 {
+		d.Unwrap().calls += int32(x)
+		return wypes.Int32(d.Unwrap().calls)
+	})
+	ctx := context.Background()
+	mods := instantiateN(t, ctx, f, insts, 2)
+	res, err := mods[0].ExportedFunction("run").Call(ctx, 5)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{15})
+	res, err = mods[1].ExportedFunction("run").Call(ctx, 1)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{11})
+}
+
+func TestWazeroInstances_DataParam_Mismatch(t *testing.T) {
+	c := is.NewRelaxed(t)
+	called := false
+	f := wypes.H1(func(d wypes.Data[*instanceData]) wypes.Void {
+		called = true
+		return wypes.Void{}
+	})
+	// the default factory sets no data
+	mods := instantiateN(t, context.Background(), f, wypes.NewWazeroInstances(nil), 1)
+	_, err := mods[0].ExportedFunction("run").Call(context.Background())
+	is.True(c, errors.Is(err, wypes.ErrDataType))
+	is.True(c, !called)
+}
+
+func TestWazeroInstances_Cancellation_ModuleClosed(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ctx := context.Background()
+	started := make(chan struct{})
+	f := wypes.H1(func(ctx wypes.Context) wypes.Int32 {
+		close(started)
+		<-ctx.Unwrap().Done()
+		return 13
+	})
+	mods := instantiateN(t, ctx, f, wypes.NewWazeroInstances(nil), 1, wypes.WithCancellation())
+	go func() {
+		<-started
+		_ = mods[0].Close(ctx)
+	}()
+	_, err := mods[0].ExportedFunction("run").Call(ctx)
+	is.True(c, errors.Is(err, wypes.ErrCanceled))
+	is.True(c, errors.Is(err, wypes.ErrModuleClosed))
+}
@@ -0,0 +1,395 @@
+//go:build !nowazero
+// +build !nowazero
+
+package wypes_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/orsinium-labs/tinytest/is"
+	"github.com/orsinium-labs/wypes"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// runGuest defines the host function in a fresh runtime
+// and calls it through a guest module with the given raw params.
+func runGuest(t testing.TB, hf wypes.HostFunc, params []uint64, opts ...wypes.LinkOption) ([]uint64, error) {
+	mod := instantiateGuest(t, hf, opts...)
+	return mod.ExportedFunction("run").Call(context.Background(), params...)
+}
+
+// instantiateGuest defines the host function as env.f in a fresh runtime
+// and instantiates a guest module that exports "run" calling it.
+func instantiateGuest(t testing.TB, hf wypes.HostFunc, opts ...wypes.LinkOption) api.Module {
+	guest := wasmGuest("env", "f", hf.ParamValueTypes(), hf.ResultValueTypes())
+	return instantiate(t, hf, guest, opts...)
+}
+
+// instantiate defines the host function as env.f in a fresh runtime
+// and instantiates the given guest module.
+func instantiate(t testing.TB, hf wypes.HostFunc, guest []byte, opts ...wypes.LinkOption) api.Module {
+	return instantiateWithRefs(t, hf, guest, nil, opts...)
+}
+
+// instantiateWithRefs is like instantiate but uses the given [wypes.Refs].
+func instantiateWithRefs(t testing.TB, hf wypes.HostFunc, guest []byte, refs wypes.Refs, opts ...wypes.LinkOption) api.Module {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	t.Cleanup(func() { r.Close(ctx) })
+	modules := wypes.Modules{"env": {"f": hf}}
+	err := modules.DefineWazero(r, refs, opts...)
+	if err != nil {
+		t.Fatalf("define host functions: %v", err)
+	}
+	mod, err := r.Instantiate(ctx, guest)
+	if err != nil {
+		t.Fatalf("instantiate guest: %v", err)
+	}
+	return mod
+}
+
+func TestWazero_Results(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H0(func() wypes.Int32 { return 13 })
+	res, err := runGuest(t, f, nil)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{13})
+}
+
+func TestWazero_TrapOnError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	called := false
+	f := wypes.H1(func(s wypes.String) wypes.Void {
+		called = true
+		return wypes.Void{}
+	})
+	_, err := runGuest(t, f, []uint64{1 << 20, 4})
+	is.Err(c, err)
+	is.True(c, strings.Contains(err.Error(), "env.f"))
+	is.True(c, strings.Contains(err.Error(), "param #0"))
+	is.True(c, !called)
+}
+
+func TestWazero_LogOnError(t *testing.T) {
+	c := is.NewRelaxed(t)
+	called := false
+	f := wypes.H1(func(s wypes.String) wypes.Int32 {
+		called = true
+		return 13
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	res, err := runGuest(t, f, []uint64{1 << 20, 4}, wypes.WithErrorPolicy(wypes.LogOnError(logger)))
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{0})
+	is.True(c, !called)
+}
+
+func TestWazero_ErrorHook(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var got error
+	hook := func(ctx context.Context, err error) error {
+		got = err
+		return nil
+	}
+	f := wypes.H1(func(s wypes.String) wypes.Void { return wypes.Void{} })
+	_, err := runGuest(t, f, []uint64{1 << 20, 4}, wypes.WithErrorPolicy(hook))
+	is.Err(is.Not(c), err)
+	is.True(c, errors.Is(got, wypes.ErrMemRead))
+}
+
+func TestWazero_Panic(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H1(func(x wypes.Int32) wypes.Int32 {
+		panic("oh no")
+	})
+	_, err := runGuest(t, f, []uint64{13})
+	is.Err(c, err)
+	is.True(c, strings.Contains(err.Error(), "env.f panicked: oh no"))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	res, err := runGuest(t, f, []uint64{13}, wypes.WithErrorPolicy(wypes.LogOnError(logger)))
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{0})
+}
+
+func TestWazero_Allocator(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H0(func() wypes.String {
+		return wypes.String{Raw: "hello"}
+	}).FlatResults()
+	malloc := wasmFunc{
+		name:    "malloc",
+		params:  []wypes.ValueType{wypes.ValueTypeI32},
+		results: []wypes.ValueType{wypes.ValueTypeI32},
+		body:    []byte{0x41, 0x80, 0x02, 0x0b}, // i32.const 256, end
+	}
+	guest := wasmGuest("env", "f", f.ParamValueTypes(), f.ResultValueTypes(), malloc)
+	mod := instantiate(t, f, guest)
+	res, err := mod.ExportedFunction("run").Call(context.Background())
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{256, 5})
+	data, _ := mod.Memory().Read(256, 5)
+	is.Equal(c, string(data), "hello")
+}
+
+func TestWazero_NoAllocator(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H0(func() wypes.String {
+		return wypes.String{Raw: "hello"}
+	})
+	_, err := runGuest(t, f, []uint64{64})
+	is.True(c, errors.Is(err, wypes.ErrNoAllocator))
+}
+
+func TestWazero_SpillResults(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H1(func(x wypes.UInt32) wypes.String {
+		return wypes.String{Offset: 256, Raw: "hello"}
+	})
+	// the guest imports the function the same as the canonical ABI does
+	i32 := wypes.ValueTypeI32
+	guest := wasmGuest("env", "f", []wypes.ValueType{i32, i32}, nil)
+	mod := instantiate(t, f, guest)
+	res, err := mod.ExportedFunction("run").Call(context.Background(), 1, 64)
+	is.Err(is.Not(c), err)
+	is.Equal(c, len(res), 0)
+	ptr, _ := mod.Memory().ReadUint32Le(64)
+	size, _ := mod.Memory().ReadUint32Le(68)
+	is.Equal(c, ptr, 256)
+	data, _ := mod.Memory().Read(ptr, size)
+	is.Equal(c, string(data), "hello")
+}
+
+func TestWazero_ResultError(t *testing.T) {
+	type R = wypes.Result[wypes.UInt32, wypes.UInt32, wypes.String]
+	c := is.NewRelaxed(t)
+	f := wypes.H1E(
+		func(retptr wypes.UInt32) (R, error) {
+			return R{Offset: uint32(retptr), DataPtr: 128}, errors.New("oops")
+		},
+		wypes.ResultError[wypes.UInt32, wypes.UInt32](func(err error) wypes.String {
+			return wypes.String{Raw: err.Error()}
+		}),
+	)
+	// the guest passes the return area pointer and expects nothing back
+	i32 := wypes.ValueTypeI32
+	guest := wasmGuest("env", "f", []wypes.ValueType{i32}, nil)
+	mod := instantiateWithRefs(t, f, guest, nil)
+	res, err := mod.ExportedFunction("run").Call(context.Background(), 64)
+	is.Err(is.Not(c), err)
+	is.Equal(c, len(res), 0)
+
+	mem := mod.Memory()
+	disc, _ := mem.ReadByte(64)
+	is.Equal(c, disc, 1)
+	ptr, _ := mem.ReadUint32Le(68)
+	size, _ := mem.ReadUint32Le(72)
+	msg, _ := mem.Read(ptr, size)
+	is.Equal(c, string(msg), "oops")
+}
+
+func TestWazero_BorrowEnded(t *testing.T) {
+	c := is.NewRelaxed(t)
+	var borrowed wypes.Borrow[string]
+	f := wypes.H1(func(b wypes.Borrow[string]) wypes.UInt32 {
+		borrowed = b
+		return wypes.UInt32(len(b.Unwrap()))
+	})
+	refs := wypes.NewMapRefs()
+	handle := refs.Put("hello")
+	guest := wasmGuest("env", "f", f.ParamValueTypes(), f.ResultValueTypes())
+	mod := instantiateWithRefs(t, f, guest, refs)
+	res, err := mod.ExportedFunction("run").Call(context.Background(), uint64(handle))
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{5})
+
+	// the borrow doesn't remove the resource but cannot be used after the call
+	_, found := refs.Get(handle, nil)
+	is.True(c, found)
+	is.True(c, !borrowed.Valid())
+	store := wypes.Store{Stack: wypes.NewSliceStack(1)}
+	borrowed.Lower(&store)
+	var lowerErr *wypes.LowerError
+	is.True(c, errors.As(store.Error, &lowerErr))
+	is.True(c, errors.Is(store.Error, wypes.ErrBorrowEnded))
+	defer func() {
+		is.Equal(c, recover(), any(wypes.ErrBorrowEnded))
+	}()
+	borrowed.Unwrap()
+}
+
+func TestWazero_MissingStubs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ctx := context.Background()
+	i32 := wypes.ValueTypeI32
+	f := wypes.H1(func(x wypes.Int32) wypes.Int32 { return x * 2 })
+	// "run" calls the defined env.f and "todo" calls the missing wasi.todo
+	guest := buildWasm(
+		[]wasmImport{
+			{module: "env", name: "f", params: []wypes.ValueType{i32}, results: []wypes.ValueType{i32}},
+			{module: "env", name: "later", params: []wypes.ValueType{i32}},
+			{module: "wasi", name: "todo"},
+		},
+		[]wasmFunc{
+			{name: "run", params: []wypes.ValueType{i32}, results: []wypes.ValueType{i32}, body: []byte{0x20, 0x00, 0x10, 0x00, 0x0b}},
+			{name: "todo", body: []byte{0x10, 0x02, 0x0b}},
+		},
+	)
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	modules := wypes.Modules{"env": {"f": f}}
+	err := modules.DefineWazero(r, nil, wypes.WithMissingStubs(guest, logger))
+	is.Err(is.Not(c), err)
+	mod, err := r.Instantiate(ctx, guest)
+	is.Err(is.Not(c), err)
+	is.True(c, strings.Contains(logs.String(), "module=env function=later"))
+	is.True(c, strings.Contains(logs.String(), "module=wasi function=todo"))
+
+	res, err := mod.ExportedFunction("run").Call(ctx, 21)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{42})
+
+	_, err = mod.ExportedFunction("todo").Call(ctx)
+	is.True(c, errors.Is(err, wypes.ErrNotImplemented))
+	is.True(c, strings.Contains(err.Error(), "not implemented: wasi.todo"))
+}
+
+func TestWazero_NoMissingStubs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ctx := context.Background()
+	guest := buildWasm([]wasmImport{{module: "env", name: "later"}}, nil)
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+	err := wypes.Modules{}.DefineWazero(r, nil)
+	is.Err(is.Not(c), err)
+	_, err = r.Instantiate(ctx, guest)
+	is.Err(c, err)
+}
+
+// wazeroRuntime is a [linkerRuntime] for the conformance suite.
+type wazeroRuntime struct {
+	runtime wazero.Runtime
+}
+
+func (r wazeroRuntime) linker() wypes.Linker {
+	return wypes.WazeroLinker{Runtime: r.runtime}
+}
+
+func (r wazeroRuntime) instantiate(guest []byte) (linkerInstance, error) {
+	mod, err := r.runtime.Instantiate(context.Background(), guest)
+	return wazeroInstance{mod}, err
+}
+
+type wazeroInstance struct {
+	mod api.Module
+}
+
+func (i wazeroInstance) call(name string, params ...uint64) ([]uint64, error) {
+	return i.mod.ExportedFunction(name).Call(context.Background(), params...)
+}
+
+func (i wazeroInstance) read(offset, size uint32) []byte {
+	data, _ := i.mod.Memory().Read(offset, size)
+	return data
+}
+
+func (i wazeroInstance) write(offset uint32, data []byte) {
+	i.mod.Memory().Write(offset, data)
+}
+
+func TestWazero_Linker(t *testing.T) {
+	testLinker(t, func(t *testing.T) linkerRuntime {
+		ctx := context.Background()
+		r := wazero.NewRuntime(ctx)
+		t.Cleanup(func() { r.Close(ctx) })
+		return wazeroRuntime{r}
+	})
+}
+
+func TestWazero_NoAllocs(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b })
+	mod := instantiateGuest(t, f)
+	run := mod.ExportedFunction("run")
+	ctx := context.Background()
+	stack := []uint64{3, 4}
+	allocs := testing.AllocsPerRun(100, func() {
+		stack[0], stack[1] = 3, 4
+		_ = run.CallWithStack(ctx, stack)
+	})
+	is.Equal(c, allocs, 0.)
+	is.Equal(c, stack[0], 7)
+}
+
+func BenchmarkWazero_Scalar(b *testing.B) {
+	f := wypes.H2(func(a, b wypes.Int32) wypes.Int32 { return a + b })
+	mod := instantiateGuest(b, f)
+	run := mod.ExportedFunction("run")
+	ctx := context.Background()
+	stack := []uint64{3, 4}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stack[0], stack[1] = 3, 4
+		_ = run.CallWithStack(ctx, stack)
+	}
+}
+
+type ctxKey struct{}
+
+func TestWazero_Context(t *testing.T) {
+	c := is.NewRelaxed(t)
+	f := wypes.H1(func(ctx wypes.Context) wypes.Int32 {
+		return ctx.Unwrap().Value(ctxKey{}).(wypes.Int32)
+	})
+	mod := instantiateGuest(t, f)
+	ctx := context.WithValue(context.Background(), ctxKey{}, wypes.Int32(42))
+	res, err := mod.ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{42})
+}
+
+func TestWazero_Cancellation(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	f := wypes.H1(func(ctx wypes.Context) wypes.Int32 {
+		cancel()
+		<-ctx.Unwrap().Done()
+		return 13
+	})
+	mod := instantiateGuest(t, f, wypes.WithCancellation())
+	_, err := mod.ExportedFunction("run").Call(ctx)
+	is.True(c, errors.Is(err, wypes.ErrCanceled))
+	is.True(c, errors.Is(err, context.Canceled))
+}
+
+func TestWazero_NoCancellation(t *testing.T) {
+	c := is.NewRelaxed(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	f := wypes.H0(func() wypes.Int32 {
+		cancel()
+		return 13
+	})
+	mod := instantiateGuest(t, f)
+	res, err := mod.ExportedFunction("run").Call(ctx)
+	is.Err(is.Not(c), err)
+	is.SliceEqual(c, res, []uint64{13})
+}
+
+func TestWazero_Metrics(t *testing.T) {
+	c := is.NewRelaxed(t)
+	collector := wypes.NewMetricsCollector()
+	f := wypes.H1(func(x wypes.Int32) wypes.Int32 { return x })
+	mod := instantiateGuest(t, f, wypes.WithMetrics(collector))
+	_, err := mod.ExportedFunction("run").Call(context.Background(), 3)
+	is.Err(is.Not(c), err)
+	is.Equal(c, collector.Snapshot()["env.f"].Calls, 1)
+}